@@ -0,0 +1,160 @@
+// Package browser keeps one Chrome session alive for a whole command, so every
+// page is rendered in the same tab and cookies are only injected once.
+package browser
+
+import (
+	"context"
+	"log"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+type cookie struct {
+	Name         string
+	Value        string
+	Domain       string
+	Path         string
+	Secure       bool
+	HTTPOnly     bool
+	ExpireWithIn int
+}
+
+type Browser struct {
+	opts    options
+	logger  *log.Logger
+	ctx     context.Context
+	cancels []context.CancelFunc
+	// one tab, renders are serialized
+	mu sync.Mutex
+}
+
+// New starts Chrome and opens the tab every render goes through.
+func New(opts ...Option) (*Browser, error) {
+	options := options{
+		headless: true,
+		timeout:  60 * time.Second,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	b := &Browser{
+		opts:   options,
+		logger: log.New(os.Stdout, "[BROWSER] ", log.LstdFlags|log.Lshortfile|log.Lmsgprefix),
+	}
+
+	allocOpts := chromedp.DefaultExecAllocatorOptions[:]
+	if !options.headless {
+		allocOpts = append(allocOpts, chromedp.Flag("headless", false))
+	}
+
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), allocOpts...)
+	ctx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(b.logger.Printf), chromedp.WithErrorf(b.logger.Printf))
+	b.ctx, b.cancels = ctx, []context.CancelFunc{cancel, allocCancel}
+
+	if err := chromedp.Run(ctx, b.setCookies()); err != nil {
+		b.Close()
+		return nil, errors.WithMessage(err, "New: start browser fail")
+	}
+
+	b.logger.Printf("%s %s %d", "[INFO] ", "browser ready, cookies -> ", len(options.cookies))
+
+	return b, nil
+}
+
+// Close shuts the tab and the Chrome process down.
+func (b *Browser) Close() {
+	for _, cancel := range b.cancels {
+		cancel()
+	}
+}
+
+func (b *Browser) setCookies() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		for _, c := range b.opts.cookies {
+			var ck cookie
+			if err := mapstructure.Decode(c, &ck); err != nil {
+				return errors.WithMessagef(err, "setCookies: build cookie fail %v", c)
+			}
+
+			params := network.SetCookie(ck.Name, ck.Value).
+				WithDomain(ck.Domain).
+				WithPath(ck.Path).
+				WithSecure(ck.Secure).
+				WithHTTPOnly(ck.HTTPOnly)
+
+			if ck.ExpireWithIn > 0 {
+				expires := cdp.TimeSinceEpoch(time.Now().Add(time.Duration(ck.ExpireWithIn) * time.Second))
+				params = params.WithExpires(&expires)
+			}
+
+			if err := params.Do(ctx); err != nil {
+				return errors.WithMessagef(err, "setCookies: set cookie fail %s", ck.Name)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Run navigates the shared tab to url and runs actions against the page.
+func (b *Browser) Run(url string, actions ...chromedp.Action) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(b.ctx, b.opts.timeout)
+	defer cancel()
+
+	if err := chromedp.Run(ctx, append([]chromedp.Action{chromedp.Navigate(url)}, actions...)...); err != nil {
+		return errors.WithMessagef(err, "Run: render fail -> %s", url)
+	}
+
+	return nil
+}
+
+// FullScreenshot captures the whole scrollable page, not only the viewport.
+func FullScreenshot(quality int64, buf *[]byte) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		_, _, content, err := page.GetLayoutMetrics().Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		width, height := int64(math.Ceil(content.Width)), int64(math.Ceil(content.Height))
+
+		if err := emulation.SetDeviceMetricsOverride(width, height, 1, false).
+			WithScreenOrientation(&emulation.ScreenOrientation{
+				Type:  emulation.OrientationTypePortraitPrimary,
+				Angle: 0,
+			}).Do(ctx); err != nil {
+			return err
+		}
+
+		*buf, err = page.CaptureScreenshot().
+			WithQuality(quality).
+			WithClip(&page.Viewport{
+				X:      content.X,
+				Y:      content.Y,
+				Width:  content.Width,
+				Height: content.Height,
+				Scale:  1,
+			}).Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		// the tab is shared, later pages must render with the default viewport
+		return emulation.ClearDeviceMetricsOverride().Do(ctx)
+	})
+}
@@ -0,0 +1,30 @@
+package browser
+
+import "time"
+
+type Option func(*options)
+
+type options struct {
+	cookies  []map[string]interface{}
+	headless bool
+	timeout  time.Duration
+}
+
+func WithCookies(cookies []map[string]interface{}) Option {
+	return func(o *options) {
+		o.cookies = cookies
+	}
+}
+
+func WithHeadless(headless bool) Option {
+	return func(o *options) {
+		o.headless = headless
+	}
+}
+
+// WithTimeout bounds a single page render, navigation included.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
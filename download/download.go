@@ -1,6 +1,9 @@
 package download
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"log"
 	"net/http"
@@ -8,25 +11,48 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/HarryBird/cdp"
+	"github.com/HarryBird/lantouzi-export/browser"
+	"github.com/HarryBird/lantouzi-export/export"
+	"github.com/HarryBird/lantouzi-export/fetcher"
+	"github.com/HarryBird/lantouzi-export/selector"
+	"github.com/HarryBird/lantouzi-export/site"
 	"github.com/PuerkitoBio/goquery"
-	"github.com/chromedp/chromedp"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
 )
 
 type downItem map[string][]string
 type downList map[string]downItem
 
 type Download struct {
-	opts   options
-	logger *log.Logger
+	opts      options
+	logger    *log.Logger
+	services  []Service
+	projects  []LoanProject
+	contracts []Contract
+	// limiter paces contract requests of a Run across workers
+	limiter *rate.Limiter
+	// notBefore holds every worker back once the site answers busy, guarded by pause
+	pause     sync.Mutex
+	notBefore time.Time
 }
 
 func New(opts ...Option) *Download {
-	options := options{}
+	options := options{
+		site:      site.Default(),
+		selectors: selector.Default(),
+		statuses:  []Status{{Code: 3, Name: "已退出"}},
+
+		concurrency: 4,
+		rps:         2,
+		burst:       1,
+		retries:     3,
+		backoff:     5 * time.Second,
+	}
 
 	for _, o := range opts {
 		o(&options)
@@ -38,8 +64,37 @@ func New(opts ...Option) *Download {
 	}
 }
 
+// Services returns the services found by the last Run.
+func (self *Download) Services() []Service {
+	return self.services
+}
+
+// Projects returns the loan projects found by the last Run.
+func (self *Download) Projects() []LoanProject {
+	return self.projects
+}
+
+// Contracts returns the files stored by the last Run.
+func (self *Download) Contracts() []Contract {
+	return self.contracts
+}
+
 func (self *Download) Run() error {
 	downs := downList{}
+	self.services = []Service{}
+	self.projects = []LoanProject{}
+	self.contracts = []Contract{}
+
+	if self.opts.fetcher == nil {
+		b, err := browser.New(browser.WithCookies(self.opts.cookies))
+		if err != nil {
+			return errors.WithMessage(err, "Run: start browser fail")
+		}
+
+		defer b.Close()
+		self.opts.fetcher = fetcher.NewChrome(b)
+		defer func() { self.opts.fetcher = nil }()
+	}
 
 	servs, err := self.getServices()
 
@@ -47,19 +102,28 @@ func (self *Download) Run() error {
 		return err
 	}
 
-	/*
-		servs := map[string]string{
-			"智选服务6月期D10482": "https://lantouzi.com/user/smartbid/order/detail?id=ltz5baf814f22b75181&smb_type=1",
-		}
-	*/
-
-	for name, url := range servs {
-		name, item, err := self.handleServ(name, url)
+	for _, serv := range servs {
+		item, projects, err := self.handleServ(&serv)
 		if err != nil {
 			return err
 		}
-		downs[name] = item
-		// break
+		downs[serv.Folder] = item
+
+		self.services = append(self.services, serv)
+		self.projects = append(self.projects, projects...)
+	}
+
+	if err := self.writeServices(); err != nil {
+		return errors.WithMessage(err, "Run: write services fail")
+	}
+
+	if err := self.writeProjects(); err != nil {
+		return errors.WithMessage(err, "Run: write projects fail")
+	}
+
+	if self.opts.metadata {
+		self.logger.Printf("%s %s %d", "[INFO] ", "metadata only, skip contracts, services -> ", len(self.services))
+		return nil
 	}
 
 	// self.logger.Printf("%s %s %s %+v", "[DEBUG]", "[Run]", "download map", downs)
@@ -67,33 +131,126 @@ func (self *Download) Run() error {
 	return self.store(downs)
 }
 
-func (self *Download) download(url, dir string, idx int) error {
+// get requests a contract url within the rate limit, a 429 or 5xx response is
+// retried after a backoff until opts.retries runs out.
+func (self *Download) get(ctx context.Context, url string) (*http.Response, error) {
+	client := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: self.opts.transport,
+	}
 
-	fileRegexp := regexp.MustCompile(`filename="([^"]+)"`)
+	for attempt := 0; ; attempt++ {
+		if err := self.wait(ctx); err != nil {
+			return nil, err
+		}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range self.opts.cookies {
+			var ck http.Cookie
+			if err := mapstructure.Decode(c, &ck); err != nil {
+				return nil, errors.WithMessagef(err, "%s %s %v", "[Download]", "build cookie fail", c)
+			}
+
+			req.AddCookie(&ck)
+		}
+
+		r, err := client.Do(req)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if r.StatusCode != http.StatusTooManyRequests && r.StatusCode < http.StatusInternalServerError {
+			return r, nil
+		}
+
+		r.Body.Close()
+
+		if attempt >= self.opts.retries {
+			return nil, errors.Errorf("%s %s %d -> %s", "[Download]", "server busy, give up, status", r.StatusCode, url)
+		}
+
+		wait := retryAfter(r.Header.Get("Retry-After"), self.opts.backoff<<uint(attempt))
+		self.logger.Printf("%s %s %s %d %s %s -> %s", "[WARN]", "[Download]", "status", r.StatusCode, "pause all workers for", wait, url)
+
+		self.hold(wait)
 	}
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// wait blocks until the limiter lets one more request through with no busy
+// pause in effect, a pause set while waiting for the limiter is waited out too.
+func (self *Download) wait(ctx context.Context) error {
+	if err := self.paused(ctx); err != nil {
+		return err
+	}
 
-	if err != nil {
+	if err := self.limiter.Wait(ctx); err != nil {
 		return err
 	}
 
-	for _, c := range self.opts.cookies {
-		var ck http.Cookie
-		if err := mapstructure.Decode(c, &ck); err != nil {
-			return errors.WithMessagef(err, "%s %s %v", "[Download]", "build cookie fail", c)
+	return self.paused(ctx)
+}
+
+// paused blocks until the busy pause is over.
+func (self *Download) paused(ctx context.Context) error {
+	for {
+		self.pause.Lock()
+		wait := time.Until(self.notBefore)
+		self.pause.Unlock()
+
+		if wait <= 0 {
+			return nil
+		}
+
+		// another worker may extend the pause meanwhile, so check again after it
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
 		}
+	}
+}
 
-		req.AddCookie(&ck)
+// hold pauses every worker for d, a pause ending later is kept.
+func (self *Download) hold(d time.Duration) {
+	self.pause.Lock()
+	defer self.pause.Unlock()
+
+	if until := time.Now().Add(d); until.After(self.notBefore) {
+		self.notBefore = until
 	}
+}
 
-	r, err := client.Do(req)
+// retryAfter reads a Retry-After header of seconds or an http date, def when it has neither.
+func retryAfter(header string, def time.Duration) time.Duration {
+	if secs, err := strconv.Atoi(strings.TrimSpace(header)); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+
+	if t, err := http.ParseTime(header); err == nil {
+		if wait := time.Until(t); wait > 0 {
+			return wait
+		}
+		return 0
+	}
+
+	return def
+}
+
+// download stores one contract file, a nil Contract means the file was empty and skipped.
+func (self *Download) download(ctx context.Context, url, dir string, idx int) (*Contract, error) {
+
+	fileRegexp := regexp.MustCompile(`filename="([^"]+)"`)
+
+	r, err := self.get(ctx, url)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	defer r.Body.Close()
@@ -104,7 +261,7 @@ func (self *Download) download(url, dir string, idx int) error {
 
 	if cLen == 0 {
 		self.logger.Printf("%s %s %s %s -> %s", "[WARN]", "[Download]", "invalid file, ignore...", dir, url)
-		return nil
+		return nil, nil
 	}
 
 	matches := fileRegexp.FindStringSubmatch(cPos)
@@ -113,32 +270,51 @@ func (self *Download) download(url, dir string, idx int) error {
 	}
 
 	if file == "" {
-		return errors.Errorf("%s %s -> %s", "download file fail, filename empty", dir, url)
+		return nil, errors.Errorf("%s %s -> %s", "download file fail, filename empty", dir, url)
 	}
 
 	filename := dir + strconv.Itoa(idx) + "_" + file
 
-	f, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR, 0755)
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0755)
 
 	if err != nil {
-		return errors.WithMessagef(err, "%s %s -> %s", "[Download]", "create file fail", filename)
+		return nil, errors.WithMessagef(err, "%s %s -> %s", "[Download]", "create file fail", filename)
 	}
 
 	defer f.Close()
 
-	_, err = io.Copy(f, r.Body)
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), r.Body)
 
 	if err != nil {
-		return errors.WithMessagef(err, "%s %s -> %s", "[Download]", "store file fail", filename)
+		return nil, errors.WithMessagef(err, "%s %s -> %s", "[Download]", "store file fail", filename)
 	}
 
 	self.logger.Printf("%s %s %s %s", "[INFO] ", "[Download]", "download file -> ", filename)
 
-	return nil
+	return &Contract{Url: url, Path: filename, Sha256: hex.EncodeToString(hash.Sum(nil)), Size: size}, nil
 
 }
 
+// job is one contract file to download.
+type job struct {
+	url     string
+	dir     string
+	idx     int
+	service string
+	project string
+}
+
+// store downloads every contract with opts.concurrency workers sharing one
+// rate limit and one busy pause, the first failure stops the rest.
 func (self *Download) store(m downList) error {
+	names := map[string]string{}
+	for _, serv := range self.services {
+		names[serv.Folder] = serv.Name
+	}
+
+	jobs := []job{}
+
 	for folder, items := range m {
 		dir := "./lantouzi/合同/" + folder
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -146,7 +322,6 @@ func (self *Download) store(m downList) error {
 		}
 
 		for name, urls := range items {
-			idx := 0
 			dir := dir + "/" + name + "/"
 
 			if err := os.MkdirAll(dir, 0755); err != nil {
@@ -155,91 +330,210 @@ func (self *Download) store(m downList) error {
 
 			self.logger.Printf("%s %s %s %s", "[INFO] ", "[Store]", "create dir -> ", dir)
 
-			for _, url := range urls {
-				idx += 1
-				if err := self.download(url, dir, idx); err != nil {
-					return err
+			for i, url := range urls {
+				jobs = append(jobs, job{url: url, dir: dir, idx: i + 1, service: names[folder], project: name})
+			}
+		}
+	}
+
+	self.limiter = rate.NewLimiter(rate.Limit(self.opts.rps), self.opts.burst)
+	self.notBefore = time.Time{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := make(chan int)
+	errs := make(chan error, len(jobs))
+	results := make([]*Contract, len(jobs))
+	wg := sync.WaitGroup{}
+
+	for w := 0; w < self.opts.concurrency; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range queue {
+				j := jobs[i]
+				c, err := self.download(ctx, j.url, j.dir, j.idx)
+				if err != nil {
+					errs <- err
+					cancel()
+					continue
+				}
+
+				if c != nil {
+					c.Service, c.Project = j.service, j.project
+					results[i] = c
 				}
-				time.Sleep(1 * time.Second)
 			}
+		}()
+	}
+
+feed:
+	for i := range jobs {
+		select {
+		case queue <- i:
+		case <-ctx.Done():
+			break feed
 		}
 	}
 
+	close(queue)
+	wg.Wait()
+	close(errs)
+
+	// the first error is the cause, later ones are workers cancelled by it
+	if err := <-errs; err != nil {
+		return err
+	}
+
+	for _, c := range results {
+		if c != nil {
+			self.contracts = append(self.contracts, *c)
+		}
+	}
+
+	self.logger.Printf("%s %s %s %d", "[INFO] ", "[Store]", "contracts -> ", len(self.contracts))
+
 	return nil
 }
 
-func (self *Download) handleServ(name, url string) (string, downItem, error) {
-	var buf string
-
+func (self *Download) handleServ(serv *Service) (downItem, []LoanProject, error) {
 	item := map[string][]string{}
+	projects := []LoanProject{}
+	name, url := serv.Name, serv.Url
+	serv.Folder = serv.OrderStatus.Folder() + "/" + name
 	self.logger.Printf("%s %s %s", "[INFO] ", "[Prepare]", url)
 
-	helper := cdp.NewHelper(url).WithInfoLogger(log.Printf).WithErrorLogger(log.Printf)
-
-	if err := helper.WithCookies(self.opts.cookies); err != nil {
-		return name, item, err
-	}
-
-	err := helper.
-		Init().
-		//WithAction(chromedp.WaitVisible(`document.querySelector("#buy_prj_relation_pager > div")`, chromedp.NodeVisible, chromedp.ByJSPath)).
-		WithAction(chromedp.WaitVisible(`document.querySelector("#buy_prj_relation_list > tr:nth-child(1)")`, chromedp.NodeVisible, chromedp.ByJSPath)).
-		WithAction(chromedp.InnerHTML(`document.querySelector("body > div.g-uc-page.clearfix.no-side > div > div.uc-order-detail")`, &buf, chromedp.NodeVisible, chromedp.ByJSPath)).
-		Run()
+	page, err := self.opts.fetcher.Fetch(fetcher.Request{
+		URL: url,
+		// Wait: "#buy_prj_relation_pager > div",
+		Wait:     self.opts.selectors.Css(selector.ProjectReady),
+		Selector: self.opts.selectors.Css(selector.DetailBody),
+		Next:     self.opts.selectors.Css(selector.ProjectNext),
+		Changes:  self.opts.selectors.Css(selector.ProjectReady),
+	})
 
 	if err != nil {
-		return name, item, errors.WithMessagef(err, "%s %s -> %s", "[Prepare]", "get service detail html", url)
+		return item, projects, errors.WithMessagef(err, "%s %s -> %s", "[Prepare]", "get service detail html", url)
 	}
 
 	// self.logger.Printf("%s %s %+v", "[DEBUG] ", "service html", buf)
 
-	dom, err := goquery.NewDocumentFromReader(strings.NewReader(buf))
+	dom, err := goquery.NewDocumentFromReader(strings.NewReader(page.HTML))
 
 	if err != nil {
-		return name, item, errors.WithMessagef(err, "%s %s -> %s", "[Prepare]", "load html to dom fail", url)
+		return item, projects, errors.WithMessagef(err, "%s %s -> %s", "[Prepare]", "load html to dom fail", url)
 	}
 
 	title := ""
-	dom.Find("a[class=a-title]").Each(func(i int, titleNode *goquery.Selection) {
+	dom.Find(self.opts.selectors.Css(selector.DetailTitle)).Each(func(i int, titleNode *goquery.Selection) {
 		title = strings.TrimSpace(titleNode.Text())
 	})
 
 	if title == "" {
-		return name, item, errors.Errorf("%s %s -> %s", "[Prepare]", "get title fail", url)
+		return item, projects, errors.Errorf("%s %s -> %s", "[Prepare]", "get title fail", url)
 	}
 
 	if title == "智选服务" {
 		self.logger.Printf("%s %s %s %v %v", "[WARN] ", "[Prepare]", "may be invalid service", name, url)
-		return name + "[死链]", item, nil
+		serv.Folder, serv.Dead = serv.Folder+"[死链]", true
+		return item, projects, nil
 	}
 
-	dom.Find("div[class=clearfix] a").Each(func(i int, nameNode *goquery.Selection) {
+	dom.Find(self.opts.selectors.Css(selector.DetailField)).Each(func(i int, fieldNode *goquery.Selection) {
+		label := fieldNode.Find(self.opts.selectors.Css(selector.DetailLabel)).Text()
+		value := fieldNode.Find(self.opts.selectors.Css(selector.DetailValue)).Text()
+
+		if err := serv.setField(label, value); err != nil {
+			self.logger.Printf("%s %s %s %s %v", "[WARN] ", "[Prepare]", "invalid service field", name, err)
+		}
+	})
+
+	dom.Find(self.opts.selectors.Css(selector.DetailAgreement)).Each(func(i int, nameNode *goquery.Selection) {
 		name := strings.TrimSpace(nameNode.Text())
-		if name == "服务协议" {
+		if name == agreement {
 			if href, exist := nameNode.Attr("href"); exist {
-				item[name] = []string{"https://lantouzi.com" + href}
+				if link, err := self.opts.site.Resolve(href); err == nil {
+					item[name] = []string{link}
+				}
 			}
 		}
 	})
 
 	//self.logger.Printf("%s %s %s %d", "[DEBUG] ", "[Prepare]", "tr nodes num", dom.Find("#buy_prj_relation_list").Find("tr").Length())
 
-	dom.Find("#buy_prj_relation_list>tr").Each(func(i int, trNode *goquery.Selection) {
-		var name string
-		trNode.Find("td").Each(func(i int, tdNode *goquery.Selection) {
-			if i == 1 {
-				if name = strings.TrimSpace(tdNode.Text()); name != "" {
-					item[name] = []string{}
-				}
+	header := []string{}
+	dom.Find(self.opts.selectors.Css(selector.ProjectHeader)).Each(func(i int, thNode *goquery.Selection) {
+		header = append(header, strings.TrimSpace(thNode.Text()))
+	})
+
+	total := self.projectTotal(dom)
 
-				// self.logger.Printf("%s %s %s %v %v", "[DEBUG] ", "[Prepare]", "name", name, i)
+	views := page.Views
+	if len(views) == 0 {
+		views = []string{page.HTML}
+	}
+
+	for i, view := range views {
+		if i > 0 {
+			self.logger.Printf("%s %s %s %d", "[INFO] ", "[Prepare]", "project page -> ", i+1)
+
+			if dom, err = goquery.NewDocumentFromReader(strings.NewReader(view)); err != nil {
+				return item, projects, errors.WithMessagef(err, "%s %s %d -> %s", "[Prepare]", "load project page to dom fail", i+1, url)
 			}
+		}
+
+		projects = append(projects, self.projectRows(dom, header, serv, item)...)
+	}
+
+	// a pager only a browser can follow leaves the rows of the first page
+	if total >= 0 && total != len(projects) {
+		self.logger.Printf("%s %s %s %d %s %d -> %s", "[WARN] ", "[Prepare]", "project count", len(projects), "differs from pager total", total, url)
+	}
+
+	// self.logger.Printf("%s %s %s %v", "[DEBUG] ", "[Prepare]", "item", item)
+	return item, projects, nil
+}
+
+// projectRows reads the loan projects of one view of the project table, and
+// collects their contract links into item.
+func (self *Download) projectRows(dom *goquery.Document, header []string, serv *Service, item downItem) []LoanProject {
+	projects := []LoanProject{}
+
+	dom.Find(self.opts.selectors.Css(selector.ProjectRow)).Each(func(i int, trNode *goquery.Selection) {
+		cells := []string{}
+		trNode.ChildrenFiltered("td").Each(func(i int, tdNode *goquery.Selection) {
+			cells = append(cells, strings.TrimSpace(tdNode.Text()))
 		})
 
-		trNode.Find("div[class=details-panel] td a").Each(func(i int, linkNode *goquery.Selection) {
+		// without a header the name is the second column
+		project := LoanProject{Service: serv.Name, ServiceUrl: serv.Url}
+		if len(cells) > 1 {
+			project.Name = cells[1]
+		}
+
+		if len(header) > 0 {
+			if err := project.setCells(header, cells); err != nil {
+				self.logger.Printf("%s %s %s %s %v", "[WARN] ", "[Prepare]", "invalid project field", serv.Name, err)
+			}
+		}
+
+		name := project.Name
+		if name != "" {
+			if _, ok := item[name]; !ok {
+				item[name] = []string{}
+			}
+			projects = append(projects, project)
+		}
+
+		trNode.Find(self.opts.selectors.Css(selector.ProjectContract)).Each(func(i int, linkNode *goquery.Selection) {
 			if _, ok := item[name]; ok {
 				if href, exist := linkNode.Attr("href"); exist {
-					item[name] = append(item[name], "https://lantouzi.com"+strings.TrimSpace(href))
+					if link, err := self.opts.site.Resolve(href); err == nil {
+						item[name] = append(item[name], link)
+					}
 					// self.logger.Printf("%s %s %s %v", "[DEBUG] ", "[Prepare]", "link", href)
 				}
 			}
@@ -251,45 +545,81 @@ func (self *Download) handleServ(name, url string) (string, downItem, error) {
 		// self.logger.Printf("%s %s %s %v %v", "[DEBUG] ", "[Prepare]", "tr html", html, err)
 	})
 
-	// self.logger.Printf("%s %s %s %v", "[DEBUG] ", "[Prepare]", "item", item)
-	return name, item, nil
+	return projects
 }
 
-func (self *Download) getServices() (map[string]string, error) {
-	var buf string
+// projectTotal reads the row count the pager shows, -1 when there is none.
+func (self *Download) projectTotal(dom *goquery.Document) int {
+	text := dom.Find(self.opts.selectors.Css(selector.ProjectTotal)).First().Text()
 
-	page := 0
-	serv := map[string]string{}
-	target := "https://lantouzi.com/user/smartbid/order/datalist?status=3&"
+	digits := regexp.MustCompile(`\d+`).FindString(text)
+	if digits == "" {
+		return -1
+	}
+
+	total, err := strconv.Atoi(digits)
+	if err != nil {
+		return -1
+	}
+
+	return total
+}
+
+// getServices lists the services of every selected status, a service met
+// under two statuses, e.g. one changing status during the run, is kept once.
+func (self *Download) getServices() ([]Service, error) {
+	servs := []Service{}
+	urls := map[string]bool{}
 
+	for _, status := range self.opts.statuses {
+		list, err := self.listServices(status)
+		if err != nil {
+			return servs, err
+		}
+
+		self.logger.Printf("%s %s %s %s %d", "[INFO] ", "[Get Service]", status.Folder(), "services -> ", len(list))
+
+		for _, s := range list {
+			if !urls[s.Url] {
+				urls[s.Url] = true
+				servs = append(servs, s)
+			}
+		}
+	}
+
+	return servs, nil
+}
+
+func (self *Download) listServices(status Status) ([]Service, error) {
+	page := 0
+	serv := []Service{}
 	for {
 		page += 1
-		url := target + "page=" + strconv.Itoa(page)
+		url, err := self.opts.site.OrderListUrl(status.Code, page)
+		if err != nil {
+			return serv, errors.WithMessagef(err, "%s %s", "[Get Service]", "build url fail")
+		}
 
 		self.logger.Printf("%s %s %s %s", "[INFO] ", "[Get Service]", "render html -> ", url)
 
-		helper := cdp.NewHelper(url).WithInfoLogger(log.Printf).WithErrorLogger(log.Printf)
-
-		if err := helper.WithCookies(self.opts.cookies); err != nil {
-			return serv, err
-		}
+		list, err := self.opts.fetcher.Fetch(fetcher.Request{
+			URL:      url,
+			Selector: self.opts.selectors.Css(selector.OrderListBody),
+		})
 
-		if err := helper.InnerHTML(
-			`document.querySelector("body > div.g-uc-page.clearfix > div.g-uc-main > div")`,
-			&buf, chromedp.NodeVisible, chromedp.ByJSPath,
-		); err != nil {
+		if err != nil {
 			return serv, errors.WithMessagef(err, "%s %s -> %s", "[Get Service]", "get service html fail", url)
 		}
 
 		// self.logger.Printf("%s %s %+v", "[DEBUG] ", "service html", buf)
 
-		dom, err := goquery.NewDocumentFromReader(strings.NewReader(buf))
+		dom, err := goquery.NewDocumentFromReader(strings.NewReader(list.HTML))
 
 		if err != nil {
 			return serv, errors.WithMessagef(err, "%s %s -> %s", "[Get Service]", "load html to dom fail", url)
 		}
 
-		liNodes := dom.Find("li")
+		liNodes := dom.Find(self.opts.selectors.Css(selector.OrderItem))
 
 		if liNodes.Length() == 0 {
 			break
@@ -298,21 +628,30 @@ func (self *Download) getServices() (map[string]string, error) {
 		liNodes.Each(func(i int, li *goquery.Selection) {
 			name := ""
 			url := ""
+			amount := ""
 
-			li.Find("div[class=name]:first-child").Each(func(ii int, nameNode *goquery.Selection) {
+			li.Find(self.opts.selectors.Css(selector.OrderName)).Each(func(ii int, nameNode *goquery.Selection) {
 				name = strings.TrimSpace(nameNode.Text())
 			})
 
-			li.Find("a[class~=actionBtn]").Each(func(ii int, urlNode *goquery.Selection) {
+			li.Find(self.opts.selectors.Css(selector.OrderLink)).Each(func(ii int, urlNode *goquery.Selection) {
 				v, exists := urlNode.Attr("href")
 
 				if exists {
-					url = strings.TrimSpace(v)
+					url, _ = self.opts.site.Resolve(v)
 				}
 			})
 
+			li.Find(self.opts.selectors.Css(selector.OrderAmount)).Each(func(ii int, amountNode *goquery.Selection) {
+				amount = strings.TrimSpace(amountNode.Text())
+			})
+
 			if name != "" && url != "" {
-				serv[name] = url
+				s := Service{Name: name, Url: url, OrderStatus: status}
+				if a, err := export.ParseAmount(amount); err == nil {
+					s.Amount = a
+				}
+				serv = append(serv, s)
 			}
 		})
 	}
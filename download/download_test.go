@@ -0,0 +1,504 @@
+package download_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HarryBird/lantouzi-export/download"
+	"github.com/HarryBird/lantouzi-export/fetcher"
+	"github.com/HarryBird/lantouzi-export/ltztest"
+)
+
+func chdir(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func services() []ltztest.Service {
+	return []ltztest.Service{
+		{
+			ID:        "s1",
+			Name:      "智选服务6月期D1",
+			Status:    3,
+			Amount:    "20,000.00",
+			Rate:      "8.50%",
+			Term:      "6个月",
+			Start:     "2019-01-02",
+			End:       "2019-07-02",
+			State:     "已到期",
+			Agreement: &ltztest.Contract{ID: "a1", File: "agreement.pdf", Body: []byte("agreement")},
+			Projects: []ltztest.Project{
+				{
+					Name: "项目A", Borrower: "张**", Amount: "5,000.00", Rate: "9.00%", Term: "12个月", Status: "还款中",
+					Contracts: []ltztest.Contract{{ID: "c1", File: "loan.pdf", Body: []byte("loan a")}},
+				},
+			},
+		},
+		{
+			ID:       "s2",
+			Name:     "智选服务",
+			Status:   3,
+			Projects: []ltztest.Project{{Name: "项目B"}},
+		},
+		{
+			ID:        "s4",
+			Name:      "智选服务1月期D4",
+			Status:    2,
+			Agreement: &ltztest.Contract{ID: "a4", File: "agreement.pdf", Body: []byte("agreement 4")},
+			Projects:  []ltztest.Project{{Name: "项目D"}},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	chdir(t)
+
+	srv := ltztest.NewServer(nil, services())
+	defer srv.Close()
+
+	f, err := fetcher.NewHTTP(nil, srv.Cookies())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := download.New(
+		download.WithCookies(srv.Cookies()),
+		download.WithFetcher(f),
+		download.WithSite(srv.Site()),
+	)
+
+	if err := d.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"./lantouzi/合同/已退出/智选服务6月期D1/服务协议/1_agreement.pdf": "agreement",
+		"./lantouzi/合同/已退出/智选服务6月期D1/项目A/1_loan.pdf":       "loan a",
+	}
+
+	for file, want := range files {
+		buf, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Errorf("missing contract %s: %v", file, err)
+			continue
+		}
+
+		if string(buf) != want {
+			t.Errorf("contract %s is %q, want %q", file, buf, want)
+		}
+	}
+
+	if _, err := os.Stat("./lantouzi/合同/已退出/智选服务[死链]"); err != nil {
+		t.Errorf("dead service folder missing: %v", err)
+	}
+
+	if n := len(d.Services()); n != 2 {
+		t.Errorf("found %d services of status 3, want 2", n)
+	}
+
+	for _, s := range d.Services() {
+		if s.Name == "智选服务6月期D1" && (s.Amount != 2000000 || s.Rate != "8.50%" || s.Term != "6个月" ||
+			s.Start.Format("2006-01-02") != "2019-01-02" || s.End.Format("2006-01-02") != "2019-07-02" || s.Status != "已到期" || s.Dead) {
+			t.Errorf("unexpected service %+v", s)
+		}
+
+		if s.Name == "智选服务" && (!s.Dead || s.Amount != 1000000) {
+			t.Errorf("unexpected dead service %+v", s)
+		}
+	}
+
+	if _, err := os.Stat("./lantouzi/合同/services.csv"); err != nil {
+		t.Errorf("services.csv missing: %v", err)
+	}
+
+	projects := d.Projects()
+	if len(projects) != 1 {
+		t.Fatalf("found %d projects, want 1: %+v", len(projects), projects)
+	}
+
+	if p := projects[0]; p.Service != "智选服务6月期D1" || p.Name != "项目A" || p.Borrower != "张**" || p.Amount != 500000 ||
+		p.Rate != "9.00%" || p.Term != "12个月" || p.Status != "还款中" {
+		t.Errorf("unexpected project %+v", p)
+	}
+
+	for _, s := range d.Services() {
+		if s.Name == "智选服务6月期D1" && projects[0].ServiceUrl != s.Url {
+			t.Errorf("project service url %s, want %s", projects[0].ServiceUrl, s.Url)
+		}
+	}
+
+	for _, file := range []string{"./lantouzi/合同/projects.csv", "./lantouzi/合同/已退出/智选服务6月期D1/projects.csv"} {
+		buf, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Errorf("missing %s: %v", file, err)
+			continue
+		}
+
+		if !strings.Contains(string(buf), "智选服务6月期D1,项目A,张**,5000.00,9.00%,12个月,还款中") {
+			t.Errorf("%s misses the project row:\n%s", file, buf)
+		}
+	}
+
+	contracts := d.Contracts()
+	if len(contracts) != 2 {
+		t.Fatalf("stored %d contracts, want 2", len(contracts))
+	}
+
+	for _, c := range contracts {
+		if c.Service != "智选服务6月期D1" || c.Sha256 == "" || c.Size != int64(len(files[c.Path])) {
+			t.Errorf("unexpected contract metadata %+v", c)
+		}
+	}
+}
+
+func TestRunMetadata(t *testing.T) {
+	chdir(t)
+
+	srv := ltztest.NewServer(nil, services())
+	defer srv.Close()
+
+	f, err := fetcher.NewHTTP(nil, srv.Cookies())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := download.New(
+		download.WithCookies(srv.Cookies()),
+		download.WithFetcher(f),
+		download.WithSite(srv.Site()),
+		download.WithMetadata(true),
+	)
+
+	if err := d.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(d.Contracts()); n != 0 {
+		t.Errorf("metadata run stored %d contracts", n)
+	}
+
+	buf, err := ioutil.ReadFile("./lantouzi/合同/services.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := []map[string]interface{}{}
+	if err := json.Unmarshal(buf, &list); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 2 {
+		t.Fatalf("services.json has %d services, want 2", len(list))
+	}
+
+	if _, err := os.Stat("./lantouzi/合同/已退出/智选服务6月期D1"); !os.IsNotExist(err) {
+		t.Errorf("metadata run created contract folders: %v", err)
+	}
+}
+
+func TestRunProjectPager(t *testing.T) {
+	chdir(t)
+
+	sv := ltztest.Service{ID: "s3", Name: "智选服务12月期D3", Status: 3}
+	for _, name := range []string{"项目1", "项目2", "项目3"} {
+		sv.Projects = append(sv.Projects, ltztest.Project{
+			Name:      name,
+			Contracts: []ltztest.Contract{{ID: name, File: "loan.pdf", Body: []byte(name)}},
+		})
+	}
+
+	srv := ltztest.NewServer(nil, []ltztest.Service{sv})
+	srv.ProjectPageSize = 2
+	srv.LinkPager = true
+	defer srv.Close()
+
+	f, err := fetcher.NewHTTP(nil, srv.Cookies())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := download.New(
+		download.WithCookies(srv.Cookies()),
+		download.WithFetcher(f),
+		download.WithSite(srv.Site()),
+	)
+
+	if err := d.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(d.Projects()); n != 3 {
+		t.Errorf("found %d projects over two pages, want 3", n)
+	}
+
+	if n := len(d.Contracts()); n != 3 {
+		t.Errorf("stored %d contracts over two pages, want 3", n)
+	}
+
+	if _, err := os.Stat("./lantouzi/合同/已退出/智选服务12月期D3/项目3/1_loan.pdf"); err != nil {
+		t.Errorf("contract of the second page missing: %v", err)
+	}
+}
+
+func TestRunProjectPagerScript(t *testing.T) {
+	chdir(t)
+
+	sv := ltztest.Service{ID: "s3", Name: "智选服务12月期D3", Status: 3}
+	for _, name := range []string{"项目1", "项目2", "项目3"} {
+		sv.Projects = append(sv.Projects, ltztest.Project{
+			Name:      name,
+			Contracts: []ltztest.Contract{{ID: name, File: "loan.pdf", Body: []byte(name)}},
+		})
+	}
+
+	srv := ltztest.NewServer(nil, []ltztest.Service{sv})
+	srv.ProjectPageSize = 2
+	defer srv.Close()
+
+	f, err := fetcher.NewHTTP(nil, srv.Cookies())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := download.New(
+		download.WithCookies(srv.Cookies()),
+		download.WithFetcher(f),
+		download.WithSite(srv.Site()),
+		download.WithRateLimit(100, 1),
+	)
+
+	// without a browser to click the pager the first page is still stored
+	if err := d.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(d.Projects()); n != 2 {
+		t.Errorf("found %d projects, want the 2 of the first page", n)
+	}
+
+	if n := len(d.Contracts()); n != 2 {
+		t.Errorf("stored %d contracts, want the 2 of the first page", n)
+	}
+}
+
+func TestRunStatuses(t *testing.T) {
+	chdir(t)
+
+	srv := ltztest.NewServer(nil, services())
+	defer srv.Close()
+
+	f, err := fetcher.NewHTTP(nil, srv.Cookies())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := download.New(
+		download.WithCookies(srv.Cookies()),
+		download.WithFetcher(f),
+		download.WithSite(srv.Site()),
+		download.WithStatuses([]download.Status{{Code: 2, Name: "持有中"}, {Code: 3, Name: "已退出"}}),
+	)
+
+	if err := d.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(d.Services()); n != 3 {
+		t.Errorf("found %d services, want 3", n)
+	}
+
+	for _, s := range d.Services() {
+		if s.Name == "智选服务1月期D4" && (s.OrderStatus.Code != 2 || s.Folder != "持有中/智选服务1月期D4") {
+			t.Errorf("unexpected status of %+v", s)
+		}
+	}
+
+	if _, err := os.Stat("./lantouzi/合同/持有中/智选服务1月期D4/服务协议/1_agreement.pdf"); err != nil {
+		t.Errorf("contract of a status 2 service missing: %v", err)
+	}
+}
+
+func TestRunBackoff(t *testing.T) {
+	chdir(t)
+
+	srv := ltztest.NewServer(nil, services())
+	defer srv.Close()
+
+	// every contract is answered busy once before it is served
+	mu := sync.Mutex{}
+	busy := map[string]int{}
+	srv.ContractStatus = func(id string) int {
+		mu.Lock()
+		defer mu.Unlock()
+
+		busy[id]++
+		if busy[id] == 1 {
+			if id == "a1" {
+				return 503
+			}
+			return 429
+		}
+		return 0
+	}
+
+	f, err := fetcher.NewHTTP(nil, srv.Cookies())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := download.New(
+		download.WithCookies(srv.Cookies()),
+		download.WithFetcher(f),
+		download.WithSite(srv.Site()),
+		download.WithConcurrency(3),
+		download.WithRateLimit(100, 3),
+		download.WithBackoff(10*time.Millisecond, 2),
+	)
+
+	if err := d.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(d.Contracts()); n != 2 {
+		t.Errorf("stored %d contracts, want 2", n)
+	}
+
+	for id, n := range busy {
+		if n != 2 {
+			t.Errorf("contract %s requested %d times, want 2", id, n)
+		}
+	}
+}
+
+func TestRunPool(t *testing.T) {
+	chdir(t)
+
+	projects := []ltztest.Project{}
+	for i := 0; i < 12; i++ {
+		id := fmt.Sprintf("c%d", i)
+		projects = append(projects, ltztest.Project{
+			Name:      "项目" + id,
+			Contracts: []ltztest.Contract{{ID: id, File: "loan.pdf", Body: []byte("loan " + id)}},
+		})
+	}
+
+	srv := ltztest.NewServer(nil, []ltztest.Service{{ID: "s1", Name: "智选服务6月期D1", Status: 3, Projects: projects}})
+	defer srv.Close()
+
+	const (
+		concurrency = 3
+		rps         = 20
+		burst       = 2
+		backoff     = 200 * time.Millisecond
+	)
+
+	// each request is held a while so the workers overlap, c3 is answered busy once
+	mu := sync.Mutex{}
+	inflight, most := 0, 0
+	starts := []time.Time{}
+	busy := time.Time{}
+	srv.ContractStatus = func(id string) int {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		if id == "c3" && busy.IsZero() {
+			busy = time.Now()
+			mu.Unlock()
+			return 429
+		}
+
+		inflight++
+		if inflight > most {
+			most = inflight
+		}
+		mu.Unlock()
+
+		time.Sleep(100 * time.Millisecond)
+
+		mu.Lock()
+		inflight--
+		mu.Unlock()
+
+		return 0
+	}
+
+	f, err := fetcher.NewHTTP(nil, srv.Cookies())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := download.New(
+		download.WithCookies(srv.Cookies()),
+		download.WithFetcher(f),
+		download.WithSite(srv.Site()),
+		download.WithConcurrency(concurrency),
+		download.WithRateLimit(rps, burst),
+		download.WithBackoff(backoff, 1),
+	)
+
+	if err := d.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(d.Contracts()); n != 12 {
+		t.Errorf("stored %d contracts, want 12", n)
+	}
+
+	if most > concurrency {
+		t.Errorf("%d requests at once, want at most %d", most, concurrency)
+	}
+
+	// a token bucket lets at most burst + rps * elapsed requests through
+	for i, at := range starts {
+		elapsed := at.Sub(starts[0]) + 10*time.Millisecond
+		if limit := burst + int(elapsed.Seconds()*rps); i+1 > limit {
+			t.Errorf("request %d after %s, the rate limit allows %d", i+1, elapsed, limit)
+		}
+	}
+
+	// no worker starts a request while the busy pause lasts
+	for _, at := range starts {
+		if at.After(busy.Add(10*time.Millisecond)) && at.Before(busy.Add(backoff-10*time.Millisecond)) {
+			t.Errorf("request %s after the busy answer, within the %s pause", at.Sub(busy), backoff)
+		}
+	}
+}
+
+func TestRunBackoffGiveUp(t *testing.T) {
+	chdir(t)
+
+	srv := ltztest.NewServer(nil, services())
+	defer srv.Close()
+
+	srv.ContractStatus = func(id string) int { return 503 }
+
+	f, err := fetcher.NewHTTP(nil, srv.Cookies())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := download.New(
+		download.WithCookies(srv.Cookies()),
+		download.WithFetcher(f),
+		download.WithSite(srv.Site()),
+		download.WithRateLimit(100, 1),
+		download.WithBackoff(time.Millisecond, 1),
+	)
+
+	if err := d.Run(); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("run with a failing server got %v, want the 503", err)
+	}
+}
@@ -0,0 +1,230 @@
+package download
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HarryBird/lantouzi-export/export"
+)
+
+// agreement is the pseudo project holding a service's own agreement.
+const agreement = "服务协议"
+
+// Status is a smartbid order status, Code is the status query of the order list.
+type Status struct {
+	Code int    `mapstructure:"code"`
+	Name string `mapstructure:"name"`
+}
+
+// DefaultStatuses names the order list statuses of lantouzi.com.
+func DefaultStatuses() []Status {
+	return []Status{
+		{Code: 1, Name: "加入中"},
+		{Code: 2, Name: "持有中"},
+		{Code: 3, Name: "已退出"},
+		{Code: 4, Name: "退出中"},
+	}
+}
+
+// Folder names the status in paths, the code when it has no name.
+func (s Status) Folder() string {
+	if s.Name == "" {
+		return strconv.Itoa(s.Code)
+	}
+
+	return s.Name
+}
+
+// Service is a smartbid order found on the order list.
+type Service struct {
+	Name string
+	Url  string
+	// OrderStatus is the order list status the service was found under
+	OrderStatus Status
+	// Folder is where its contracts are stored, <status>/<Name> with a [死链]
+	// suffix for dead services.
+	Folder string
+	Dead   bool
+	// Amount is 加入金额, from the order list, the detail page wins when it shows one
+	Amount export.Amount
+	// Rate is 预期年化 as shown, e.g. 8.50%
+	Rate string
+	// Term is 服务期限 as shown, e.g. 6个月
+	Term  string
+	Start time.Time
+	End   time.Time
+	// Status is 状态 as shown on the detail page
+	Status string
+}
+
+// setField fills the field a detail page label stands for, unknown labels are ignored.
+func (s *Service) setField(label, value string) error {
+	label = strings.TrimRight(strings.TrimSpace(label), ":：")
+	value = strings.TrimSpace(value)
+
+	if value == "" {
+		return nil
+	}
+
+	switch {
+	case strings.Contains(label, "金额"):
+		a, err := export.ParseAmount(value)
+		if err != nil {
+			return err
+		}
+		s.Amount = a
+	case strings.Contains(label, "年化"), strings.Contains(label, "利率"):
+		s.Rate = value
+	case strings.Contains(label, "期限"):
+		s.Term = value
+	case strings.Contains(label, "起息"), strings.Contains(label, "开始"), strings.Contains(label, "加入日期"):
+		t, err := export.ParseTime(value)
+		if err != nil {
+			return err
+		}
+		s.Start = t
+	case strings.Contains(label, "到期"), strings.Contains(label, "结束"):
+		t, err := export.ParseTime(value)
+		if err != nil {
+			return err
+		}
+		s.End = t
+	case strings.Contains(label, "状态"):
+		s.Status = value
+	}
+
+	return nil
+}
+
+// LoanProject is a row of the 债权 table of a service detail page, a loan
+// part of the service's money sits in.
+type LoanProject struct {
+	Service string
+	// ServiceUrl is the Url of the service, names are not unique across orders
+	ServiceUrl string
+	Name       string
+	// Borrower is the borrower as the site masks it
+	Borrower string
+	// Amount is the matched amount
+	Amount export.Amount
+	Rate   string
+	Term   string
+	// Status is the repayment status as shown
+	Status string
+	// Extra keeps the columns the fields above do not cover, by header
+	Extra map[string]string
+}
+
+// projectColumns are the headers a LoanProject does not read from.
+var projectColumns = map[string]bool{"序号": true, "操作": true}
+
+// projectLabels are the exact headers of each LoanProject field.
+var projectLabels = map[string]string{
+	"项目":   "name",
+	"项目名称": "name",
+	"借款项目": "name",
+	"借款人":  "borrower",
+	"借款金额": "amount",
+	"匹配金额": "amount",
+	"年化利率": "rate",
+	"期限":   "term",
+	"状态":   "status",
+	"还款状态": "status",
+}
+
+// projectField is the field a header stands for, exact labels first and
+// then by substring, "" when it is none of them.
+func projectField(label string) string {
+	if field, ok := projectLabels[label]; ok {
+		return field
+	}
+
+	switch {
+	case strings.Contains(label, "年化"), strings.Contains(label, "利率"):
+		return "rate"
+	case strings.Contains(label, "期限"):
+		return "term"
+	case strings.Contains(label, "状态"):
+		return "status"
+	case strings.Contains(label, "金额"):
+		return "amount"
+	case strings.Contains(label, "借款人"), strings.Contains(label, "借款方"):
+		return "borrower"
+	case strings.Contains(label, "名称"):
+		return "name"
+	}
+
+	return ""
+}
+
+// setCells fills the fields from a table row keyed by the table header. A
+// field is read from one column only, exact labels win, other columns go to Extra.
+func (p *LoanProject) setCells(header, cells []string) error {
+	p.Extra = map[string]string{}
+
+	// the column each field is read from, exact labels claim theirs first
+	columns := map[string]int{}
+	for _, exact := range []bool{true, false} {
+		for i, label := range header {
+			if _, ok := projectLabels[label]; ok != exact {
+				continue
+			}
+
+			field := projectField(label)
+			if _, taken := columns[field]; field != "" && !taken {
+				columns[field] = i
+			}
+		}
+	}
+
+	for i, label := range header {
+		if i >= len(cells) || projectColumns[label] {
+			continue
+		}
+
+		value := cells[i]
+
+		field := projectField(label)
+		if field == "" || columns[field] != i {
+			p.Extra[label] = value
+			continue
+		}
+
+		switch field {
+		case "name":
+			p.Name = value
+		case "borrower":
+			p.Borrower = value
+		case "amount":
+			if value == "" {
+				continue
+			}
+			a, err := export.ParseAmount(value)
+			if err != nil {
+				return err
+			}
+			p.Amount = a
+		case "rate":
+			p.Rate = value
+		case "term":
+			p.Term = value
+		case "status":
+			p.Status = value
+		}
+	}
+
+	return nil
+}
+
+// Contract is a downloaded contract file.
+type Contract struct {
+	Service string
+	// Project is the loan name, or 服务协议 for the service agreement
+	Project string
+	Url     string
+	Path    string
+	// Sha256 is the hex digest of the file content
+	Sha256 string
+	Size   int64
+}
@@ -0,0 +1,23 @@
+package download
+
+import (
+	"testing"
+)
+
+func TestSetCells(t *testing.T) {
+	header := []string{"序号", "项目名称", "项目金额", "项目期限", "项目状态", "借款人", "借款金额", "年化利率", "操作"}
+	cells := []string{"1", "项目A", "100,000.00", "12个月", "还款中", "张**", "5,000.00", "9.00%", "合同"}
+
+	p := LoanProject{}
+	if err := p.setCells(header, cells); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Name != "项目A" || p.Borrower != "张**" || p.Amount != 500000 || p.Rate != "9.00%" || p.Term != "12个月" || p.Status != "还款中" {
+		t.Errorf("unexpected fields %+v", p)
+	}
+
+	if len(p.Extra) != 1 || p.Extra["项目金额"] != "100,000.00" {
+		t.Errorf("extra %v, want only 项目金额", p.Extra)
+	}
+}
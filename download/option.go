@@ -1,14 +1,39 @@
 package download
 
+import (
+	"net/http"
+	"time"
+
+	"github.com/HarryBird/lantouzi-export/browser"
+	"github.com/HarryBird/lantouzi-export/fetcher"
+	"github.com/HarryBird/lantouzi-export/selector"
+	"github.com/HarryBird/lantouzi-export/site"
+)
+
 type Option func(*options)
 
 type options struct {
-	cookies []map[string]interface{}
-	url     string
-	name    string
-	screen  bool
-	parse   bool
-	column  int
+	cookies   []map[string]interface{}
+	site      site.Site
+	url       string
+	name      string
+	screen    bool
+	parse     bool
+	column    int
+	fetcher   fetcher.Fetcher
+	transport http.RoundTripper
+	selectors selector.Profile
+	// metadata only collects services, no contract is downloaded
+	metadata bool
+	statuses []Status
+	// concurrency is how many contracts download at once
+	concurrency int
+	// rps and burst bound contract requests of the whole run
+	rps   float64
+	burst int
+	// retries and backoff answer 429 and 5xx responses
+	retries int
+	backoff time.Duration
 }
 
 func WithCookies(cookies []map[string]interface{}) Option {
@@ -16,3 +41,90 @@ func WithCookies(cookies []map[string]interface{}) Option {
 		o.cookies = cookies
 	}
 }
+
+// WithSite points the downloader at another host, lantouzi.com by default.
+func WithSite(s site.Site) Option {
+	return func(o *options) {
+		o.site = s.WithDefaults()
+	}
+}
+
+// WithBrowser renders pages in a shared browser session.
+func WithBrowser(b *browser.Browser) Option {
+	return func(o *options) {
+		o.fetcher = fetcher.NewChrome(b)
+	}
+}
+
+// WithFetcher replaces how pages are rendered, Run starts its own browser otherwise.
+func WithFetcher(f fetcher.Fetcher) Option {
+	return func(o *options) {
+		o.fetcher = f
+	}
+}
+
+// WithTransport replaces how contract files are downloaded.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = rt
+	}
+}
+
+// WithSelectors reads pages with another selector profile than selector.Default.
+func WithSelectors(p selector.Profile) Option {
+	return func(o *options) {
+		o.selectors = p
+	}
+}
+
+// WithMetadata only collects and writes the services, contracts are not downloaded.
+func WithMetadata(metadata bool) Option {
+	return func(o *options) {
+		o.metadata = metadata
+	}
+}
+
+// WithStatuses picks the order list statuses to collect, 已退出 by default.
+func WithStatuses(statuses []Status) Option {
+	return func(o *options) {
+		if len(statuses) > 0 {
+			o.statuses = statuses
+		}
+	}
+}
+
+// WithConcurrency downloads n contracts at once, 4 by default.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithRateLimit caps contract requests at rps per second with bursts of burst,
+// shared by every worker, 2 per second with a burst of 1 by default.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(o *options) {
+		if rps > 0 {
+			o.rps = rps
+		}
+		if burst > 0 {
+			o.burst = burst
+		}
+	}
+}
+
+// WithBackoff retries a contract answered with 429 or 5xx up to retries times,
+// pausing every worker for Retry-After or base doubled on every attempt, 3 times
+// from 5s by default.
+func WithBackoff(base time.Duration, retries int) Option {
+	return func(o *options) {
+		if base > 0 {
+			o.backoff = base
+		}
+		if retries >= 0 {
+			o.retries = retries
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package download
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	servicesDir  = "./lantouzi/合同/"
+	serviceDate  = "2006-01-02"
+	servicesFile = "services"
+)
+
+var serviceHeader = []string{"服务名称", "订单状态", "加入金额", "预期年化", "服务期限", "起息日", "到期日", "状态", "死链", "链接"}
+
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(serviceDate)
+}
+
+func (s Service) record() []string {
+	return []string{s.Name, s.OrderStatus.Folder(), s.Amount.String(), s.Rate, s.Term, date(s.Start), date(s.End), s.Status, strconv.FormatBool(s.Dead), s.Url}
+}
+
+// serviceJSON keeps money as a string in yuan, like the transaction json.
+type serviceJSON struct {
+	Name       string `json:"name"`
+	StatusCode int    `json:"status_code"`
+	StatusName string `json:"status_name"`
+	Amount     string `json:"amount"`
+	Rate       string `json:"rate,omitempty"`
+	Term       string `json:"term,omitempty"`
+	Start      string `json:"start,omitempty"`
+	End        string `json:"end,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Dead       bool   `json:"dead"`
+	Folder     string `json:"folder"`
+	Url        string `json:"url"`
+}
+
+// writeServices writes services.csv and services.json next to the contract folders.
+func (self *Download) writeServices() error {
+	if err := os.MkdirAll(servicesDir, 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(servicesDir+servicesFile+".csv", os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	// BOM so Excel opens the file as UTF-8
+	if _, err := f.WriteString("\xEF\xBB\xBF"); err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	if err := w.Write(serviceHeader); err != nil {
+		return err
+	}
+
+	list := make([]serviceJSON, 0, len(self.services))
+
+	for _, s := range self.services {
+		if err := w.Write(s.record()); err != nil {
+			return err
+		}
+
+		list = append(list, serviceJSON{
+			Name:       s.Name,
+			StatusCode: s.OrderStatus.Code,
+			StatusName: s.OrderStatus.Name,
+			Amount:     s.Amount.String(),
+			Rate:       s.Rate,
+			Term:       s.Term,
+			Start:      date(s.Start),
+			End:        date(s.End),
+			Status:     s.Status,
+			Dead:       s.Dead,
+			Folder:     s.Folder,
+			Url:        s.Url,
+		})
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	buf, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	self.logger.Printf("%s %s %s", "[INFO] ", "[Services]", "write services -> "+servicesDir+servicesFile+".csv")
+
+	return ioutil.WriteFile(servicesDir+servicesFile+".json", append(buf, '\n'), 0755)
+}
+
+const projectsFile = "projects.csv"
+
+var projectHeader = []string{"服务名称", "项目名称", "借款人", "匹配金额", "年化利率", "期限", "还款状态", "服务链接"}
+
+// writeProjectsCSV writes projects with the typed columns first and every
+// other column of the site's table after them.
+func writeProjectsCSV(file string, projects []LoanProject) error {
+	extra := []string{}
+	seen := map[string]bool{}
+	for _, p := range projects {
+		for label := range p.Extra {
+			if !seen[label] {
+				seen[label] = true
+				extra = append(extra, label)
+			}
+		}
+	}
+	sort.Strings(extra)
+
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	if _, err := f.WriteString("\xEF\xBB\xBF"); err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	if err := w.Write(append(append([]string{}, projectHeader...), extra...)); err != nil {
+		return err
+	}
+
+	for _, p := range projects {
+		record := []string{p.Service, p.Name, p.Borrower, p.Amount.String(), p.Rate, p.Term, p.Status, p.ServiceUrl}
+		for _, label := range extra {
+			record = append(record, p.Extra[label])
+		}
+
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// writeProjects writes every loan project into projects.csv next to the
+// contract folders, and each service's own into its folder.
+func (self *Download) writeProjects() error {
+	if err := os.MkdirAll(servicesDir, 0755); err != nil {
+		return err
+	}
+
+	if err := writeProjectsCSV(servicesDir+projectsFile, self.projects); err != nil {
+		return err
+	}
+
+	self.logger.Printf("%s %s %s", "[INFO] ", "[Projects]", "write projects -> "+servicesDir+projectsFile)
+
+	if self.opts.metadata {
+		return nil
+	}
+
+	for _, s := range self.services {
+		projects := []LoanProject{}
+		for _, p := range self.projects {
+			if p.ServiceUrl == s.Url {
+				projects = append(projects, p)
+			}
+		}
+
+		if len(projects) == 0 {
+			continue
+		}
+
+		dir := servicesDir + s.Folder + "/"
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		if err := writeProjectsCSV(dir+projectsFile, projects); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
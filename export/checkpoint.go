@@ -0,0 +1,81 @@
+package export
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const checkpointFile = "checkpoint.json"
+
+// checkpoint is what a Run has collected so far, written after every page.
+type checkpoint struct {
+	Url     string
+	Page    int
+	Schema  Schema
+	Records []Transaction
+	Skipped []RowError
+}
+
+func (e *Export) saveCheckpoint(page int) error {
+	if err := os.MkdirAll(e.dir(), 0755); err != nil {
+		return err
+	}
+
+	buf, err := json.Marshal(checkpoint{
+		Url:     e.opts.url,
+		Page:    page,
+		Schema:  e.schema,
+		Records: e.records,
+		Skipped: e.skipped,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "saveCheckpoint: encode fail")
+	}
+
+	// write aside and rename, a crash must never leave a truncated checkpoint
+	file := e.dir() + checkpointFile
+	if err := ioutil.WriteFile(file+".tmp", buf, 0755); err != nil {
+		return err
+	}
+
+	return os.Rename(file+".tmp", file)
+}
+
+// loadCheckpoint returns nil when there is nothing to resume.
+func (e *Export) loadCheckpoint() (*checkpoint, error) {
+	buf, err := ioutil.ReadFile(e.dir() + checkpointFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	cp := checkpoint{}
+	if err := json.Unmarshal(buf, &cp); err != nil {
+		return nil, errors.WithMessage(err, "loadCheckpoint: decode fail")
+	}
+
+	if cp.Url != e.opts.url {
+		e.logger.Printf("%s %s %s", "[WARN] ", "checkpoint url differs, ignore -> ", cp.Url)
+		return nil, nil
+	}
+
+	for i := range cp.Records {
+		cp.Records[i].Time = cp.Records[i].Time.In(location)
+	}
+
+	return &cp, nil
+}
+
+func (e *Export) removeCheckpoint() error {
+	if err := os.Remove(e.dir() + checkpointFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+const (
+	csvTimeLayout = "2006-01-02 15:04:05"
+	colPage       = "页码"
+	colID         = "流水号"
+)
+
+// csvWriter keeps the BOM so Excel opens the file as UTF-8.
+type csvWriter struct {
+	// source appends 页码 after the page's own columns and 流水号
+	source bool
+}
+
+func (csvWriter) Ext() string {
+	return "csv"
+}
+
+func (c csvWriter) Write(out io.Writer, schema Schema, records []Transaction) error {
+	if _, err := io.WriteString(out, "\xEF\xBB\xBF"); err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(out)
+
+	if err := w.Write(csvHeader(schema, c.source)); err != nil {
+		return err
+	}
+
+	for _, tx := range records {
+		if err := w.Write(csvRecord(tx, schema, c.source)); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+// csvHeader follows the page's own column order with the ID appended, and the
+// source page before it when source is set.
+func csvHeader(schema Schema, source bool) []string {
+	header := append([]string{}, schema.Columns...)
+	if source {
+		header = append(header, colPage)
+	}
+
+	return append(header, colID)
+}
+
+func csvRecord(tx Transaction, schema Schema, source bool) []string {
+	record := []string{}
+
+	for _, name := range schema.Columns {
+		switch name {
+		case colAmount:
+			record = append(record, tx.SignedString())
+		case colDesc:
+			record = append(record, tx.Description)
+		case colBalance:
+			b := ""
+			if tx.Balance != nil {
+				b = tx.Balance.String()
+			}
+			record = append(record, b)
+		case colTime:
+			record = append(record, tx.Time.In(location).Format(csvTimeLayout))
+		default:
+			record = append(record, "")
+		}
+	}
+
+	if source {
+		record = append(record, strconv.Itoa(tx.Page))
+	}
+
+	return append(record, tx.ID)
+}
@@ -1,214 +1,434 @@
 package export
 
 import (
-	"encoding/csv"
 	"io/ioutil"
 	"log"
+	"net/url"
 	"os"
-	"regexp"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 
-	"github.com/HarryBird/cdp"
-	"github.com/chromedp/chromedp"
+	"github.com/HarryBird/lantouzi-export/browser"
+	"github.com/HarryBird/lantouzi-export/fetcher"
+	"github.com/HarryBird/lantouzi-export/selector"
+	"github.com/HarryBird/lantouzi-export/site"
 )
 
-var (
-	tdRegexp = regexp.MustCompile(`<td[^>]*>\s*(.*)\s*<\/td[^>]*>`)
-	aRegexp  = regexp.MustCompile(`<[^>]+>`)
-)
+// pageSize is the number of rows asked for on every trade list page.
+const pageSize = 10
 
 type Export struct {
 	opts    options
 	logger  *log.Logger
-	records [][]string
+	records []Transaction
+	skipped []RowError
+	schema  Schema
+	breaks  []Break
+	// duplicates are rows a shift of the list pushed onto the next page
+	duplicates []Transaction
 }
 
 func New(opts ...Option) *Export {
-	options := options{}
+	options := options{
+		selectors: selector.Default(),
+		formats:   []string{"csv"},
+		accounts:  DefaultAccounts(),
+		verify:    true,
+		delay:     500 * time.Millisecond,
+	}
 
 	for _, o := range opts {
 		o(&options)
 	}
 
 	return &Export{
-		opts:    options,
-		logger:  log.New(os.Stdout, "[EXPORTER] ", log.LstdFlags|log.Lshortfile|log.Lmsgprefix),
-		records: [][]string{[]string{"交易金额", "说明", "账户余额", "交易时间"}},
+		opts:   options,
+		logger: log.New(os.Stdout, "[EXPORTER] ", log.LstdFlags|log.Lshortfile|log.Lmsgprefix),
 	}
 }
 
+// Transactions returns the records collected by the last Run.
+func (e *Export) Transactions() []Transaction {
+	return e.records
+}
+
+// Schema returns the columns detected by the last Run.
+func (e *Export) Schema() Schema {
+	return e.schema
+}
+
+// Skipped returns the rows the last Run could not interpret.
+func (e *Export) Skipped() []RowError {
+	return e.skipped
+}
+
+// Duplicates returns the rows the last Run dropped as already read.
+func (e *Export) Duplicates() []Transaction {
+	return e.duplicates
+}
+
+// Breaks returns where the balance chain of the last Run is broken, see VerifyBalance.
+func (e *Export) Breaks() []Break {
+	return e.breaks
+}
+
 func (e *Export) Run() error {
-	if e.opts.column == 4 {
-		e.records = [][]string{[]string{"交易金额", "说明", "账户余额", "交易时间"}}
-	} else if e.opts.column == 3 {
-		e.records = [][]string{[]string{"交易金额", "说明", "交易时间"}}
+	e.records = []Transaction{}
+	e.skipped = []RowError{}
+	e.schema = Schema{}
+	e.breaks = []Break{}
+	e.duplicates = []Transaction{}
+
+	if e.opts.fetcher == nil {
+		b, err := browser.New(browser.WithCookies(e.opts.cookies))
+		if err != nil {
+			return errors.WithMessage(err, "Run: start browser fail")
+		}
+
+		defer b.Close()
+		e.opts.fetcher = fetcher.NewChrome(b)
+		defer func() { e.opts.fetcher = nil }()
 	}
 
-	var buf string
-	var screen []byte
 	page := 1
-	size := 10
+	size := pageSize
+
+	if e.opts.resume {
+		cp, err := e.loadCheckpoint()
+		if err != nil {
+			return errors.WithMessage(err, "Run: load checkpoint fail")
+		}
+
+		if cp != nil {
+			e.logger.Printf("%s %s %d", "[INFO] ", "resume after page -> ", cp.Page)
+			e.records, e.skipped, e.schema = cp.Records, cp.Skipped, cp.Schema
+			page = cp.Page + 1
+		}
+	}
+
+	// the rows of the page before as they were read, see dedup
+	prev := []Transaction{}
+	for _, tx := range e.records {
+		if tx.Page == page-1 {
+			prev = append(prev, tx)
+		}
+	}
+
+	var existing []Transaction
+	var seen *known
+
+	if e.opts.incremental {
+		if !e.hasFormat("csv") {
+			e.logger.Printf("%s %s", "[WARN] ", "incremental reads record.csv, but csv is not among formats")
+		}
+
+		var err error
+		if existing, err = e.loadExisting(); err != nil {
+			return errors.WithMessage(err, "Run: load existing records fail")
+		}
+
+		seen = newKnown(existing)
+		e.logger.Printf("%s %s %d %s %s", "[INFO] ", "incremental, known records -> ", len(existing), "newest -> ", seen.newest.Format(csvTimeLayout))
+	}
 
 	for {
-		url := e.opts.url + "page=" + strconv.Itoa(page) + "&size=" + strconv.Itoa(size)
+		url, err := e.pageUrl(page, size)
+		if err != nil {
+			return errors.WithMessage(err, "Run: build page url fail")
+		}
+
 		e.logger.Printf("%s %s %s", "[INFO] ", "render url -> ", url)
 
-		if err := e.html(url, &buf); err != nil {
+		capture, err := e.capture(url)
+		if err != nil {
 			return errors.WithMessagef(err, "Run: render html fail -> %s", url)
 		}
 
 		e.logger.Printf("%s %s", "[INFO] ", "parse html...")
-		records, err := e.parse(&buf)
+		records, invalid, err := e.parse(&capture.HTML, page)
 
 		if err != nil {
 			return errors.WithMessage(err, "Run: parse html fail")
 		}
 
-		if len(records) == 0 {
+		for _, row := range invalid {
+			e.logger.Printf("%s %s %s", "[WARN] ", "skip invalid row -> ", row.Error())
+		}
+		e.skipped = append(e.skipped, invalid...)
+
+		if len(records) == 0 && len(invalid) == 0 {
 			e.logger.Printf("%s %s", "[INFO] ", "no next page...")
 			break
 		}
 
+		read := records
+		if records, err = e.dedup(records, prev, page, size); err != nil {
+			return errors.WithMessagef(err, "Run: check page shift fail -> %s", url)
+		}
+		prev = read
+
+		reached := false
+		if len(existing) > 0 {
+			records, reached = seen.filter(records)
+		}
+
 		e.logger.Printf("%s %s", "[INFO] ", "get some records...")
 		e.records = append(e.records, records...)
 
 		if e.opts.screen {
-			e.logger.Printf("%s %s", "[INFO] ", "screen...")
-			if err := e.screen(url, &screen); err != nil {
-				return errors.WithMessagef(err, "Run: get screen fail -> %s", url)
-			}
-
 			e.logger.Printf("%s %s %s", "[INFO] ", "store screen file ...", "page-"+strconv.Itoa(page))
-			if err := e.store(&screen, page); err != nil {
+			if err := e.store(capture.Screen, page, ".png"); err != nil {
 				return errors.WithMessagef(err, "Run: store screen fail -> %s", url)
 			}
 		}
 
+		if e.opts.archive {
+			e.logger.Printf("%s %s %s", "[INFO] ", "store html file ...", "page-"+strconv.Itoa(page))
+			if err := e.store([]byte(capture.Document), page, ".html"); err != nil {
+				return errors.WithMessagef(err, "Run: store html fail -> %s", url)
+			}
+		}
+
+		if err := e.saveCheckpoint(page); err != nil {
+			return errors.WithMessagef(err, "Run: save checkpoint fail -> %s", url)
+		}
+
+		if reached {
+			e.logger.Printf("%s %s", "[INFO] ", "reach known records...")
+			break
+		}
+
 		/*
 			if page%3 == 0 {
 				break
 			}
 		*/
 
-		time.Sleep(500 * time.Millisecond)
+		time.Sleep(e.opts.delay)
 		page += 1
 	}
 
+	if len(existing) > 0 {
+		e.logger.Printf("%s %s %d", "[INFO] ", "new records -> ", len(e.records))
+		e.records = append(e.records, existing...)
+
+		// the known rows moved down by the new ones, number them as the list shows them now
+		for i := range e.records {
+			e.records[i].Page, e.records[i].Row = i/size+1, i%size+1
+		}
+	}
+
+	assignIDs(e.records)
+
+	if e.schema.Balance() {
+		e.breaks = VerifyBalance(e.records)
+		for _, b := range e.breaks {
+			e.logger.Printf("%s %s %s", "[WARN] ", "balance chain break -> ", b.Error())
+		}
+	}
+
 	if e.opts.parse {
-		if err := e.csv(); err != nil {
-			return errors.WithMessagef(err, "Run: export to csv fail")
+		for _, format := range e.opts.formats {
+			w, err := e.writer(format)
+			if err != nil {
+				return errors.WithMessage(err, "Run: export fail")
+			}
+
+			if err := e.write(w); err != nil {
+				return errors.WithMessagef(err, "Run: export to %s fail", format)
+			}
 		}
 	}
 
+	if err := e.removeCheckpoint(); err != nil {
+		return errors.WithMessage(err, "Run: remove checkpoint fail")
+	}
+
+	if e.opts.verify && len(e.breaks) > 0 {
+		return errors.Errorf("Run: balance chain broken at %d rows, first at %s", len(e.breaks), e.breaks[0].Error())
+	}
+
 	// e.logger.Printf("%s %s %+v", "[DEBUG] ", "all records", e.records)
 	e.logger.Printf("%s %s", "[INFO] ", "DONE~")
 
 	return nil
 }
 
-func (e *Export) csv() error {
-	dir := "./lantouzi/流水/" + e.opts.name + "/"
+// dedup drops the rows on top of a page that a row added on top of the list
+// pushed over from the page before, prev as it was read. Rows repeating the
+// end of prev only count as pushed over when reading that page again shows it
+// moved down, identical rows straddling two pages are kept otherwise.
+func (e *Export) dedup(records, prev []Transaction, page, size int) ([]Transaction, error) {
+	n := overlap(prev, records)
+	if n == 0 {
+		return records, nil
+	}
 
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
+	url, err := e.pageUrl(page-1, size)
+	if err != nil {
+		return nil, err
 	}
 
-	file := dir + "record.csv"
+	capture, err := e.capture(url)
+	if err != nil {
+		return nil, err
+	}
 
-	f, err := os.OpenFile(file, os.O_CREATE|os.O_RDWR, 0755)
+	again, _, err := e.parse(&capture.HTML, page-1)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	defer f.Close()
+	shift := shifted(prev, again)
+	if shift > n {
+		shift = n
+	}
 
-	f.WriteString("\xEF\xBB\xBF")
+	for _, tx := range records[:shift] {
+		e.logger.Printf("%s %s page %d row %d %s %s %s", "[WARN] ", "drop duplicate row -> ", tx.Page, tx.Row, tx.Time.In(location).Format(csvTimeLayout), tx.SignedString(), tx.Description)
+		e.duplicates = append(e.duplicates, tx)
+	}
 
-	w := csv.NewWriter(f)
+	return records[shift:], nil
+}
 
-	if err := w.WriteAll(e.records); err != nil {
-		return err
+func sameRows(a, b []Transaction) bool {
+	if len(a) != len(b) {
+		return false
 	}
 
-	w.Flush()
+	for i := range a {
+		if txKey(a[i]) != txKey(b[i]) {
+			return false
+		}
+	}
 
-	return nil
+	return true
 }
 
-func (e *Export) store(buf *[]byte, page int) error {
-	dir := "./lantouzi/流水/" + e.opts.name + "/"
+// overlap is the number of rows ending prev that start next again.
+func overlap(prev, next []Transaction) int {
+	n := len(prev)
+	if len(next) < n {
+		n = len(next)
+	}
 
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
+	for ; n > 0; n-- {
+		if sameRows(prev[len(prev)-n:], next[:n]) {
+			return n
+		}
 	}
 
-	file := dir + "page-" + strconv.Itoa(page) + ".png"
+	return 0
+}
 
-	if err := ioutil.WriteFile(file, *buf, 0755); err != nil {
-		return err
+// shifted is how many rows a page moved down between the two reads of it,
+// len(after) when none of its rows are left.
+func shifted(before, after []Transaction) int {
+	for k := 0; k < len(after); k++ {
+		n := len(after) - k
+		if len(before) < n {
+			n = len(before)
+		}
+
+		if sameRows(before[:n], after[k:k+n]) {
+			return k
+		}
 	}
 
-	return nil
+	return len(after)
 }
 
-func (e *Export) screen(url string, buf *[]byte) error {
-	helper := cdp.NewHelper(url).WithInfoLogger(log.Printf).WithErrorLogger(log.Printf)
+func (e *Export) pageUrl(page, size int) (string, error) {
+	return site.WithQuery(e.opts.url, url.Values{
+		"page": {strconv.Itoa(page)},
+		"size": {strconv.Itoa(size)},
+	})
+}
 
-	if err := helper.WithCookies(e.opts.cookies); err != nil {
-		return err
+func (e *Export) dir() string {
+	return "./lantouzi/流水/" + e.opts.name + "/"
+}
+
+func (e *Export) hasFormat(format string) bool {
+	for _, f := range e.opts.formats {
+		if f == format {
+			return true
+		}
 	}
 
-	if err := helper.FullScreen(100, buf); err != nil {
-		return err
+	return false
+}
+
+// writer is NewWriter with the journal formats bound to the configured accounts
+// and csv to the configured columns.
+func (e *Export) writer(format string) (Writer, error) {
+	w, err := NewWriter(format)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	if j, ok := w.(journalWriter); ok {
+		j.accounts = e.opts.accounts
+		return j, nil
+	}
+
+	if c, ok := w.(csvWriter); ok {
+		c.source = e.opts.source
+		return c, nil
+	}
 
+	return w, nil
 }
 
-func (e *Export) html(url string, buf *string) error {
-	helper := cdp.NewHelper(url).WithInfoLogger(log.Printf).WithErrorLogger(log.Printf)
+func (e *Export) write(w Writer) error {
+	dir := e.dir()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	file := dir + "record." + w.Ext()
 
-	if err := helper.WithCookies(e.opts.cookies); err != nil {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0755)
+	if err != nil {
 		return err
 	}
 
-	if err := helper.InnerHTML(
-		`document.querySelector("body > div.g-uc-page.clearfix > div.g-uc-main > div > div.bd > div:nth-child(2) > table")`,
-		buf, chromedp.NodeVisible, chromedp.ByJSPath,
-	); err != nil {
+	defer f.Close()
+
+	if err := w.Write(f, e.schema, e.records); err != nil {
 		return err
 	}
 
-	return nil
+	return f.Close()
 }
 
-func (e *Export) parse(buf *string) ([][]string, error) {
-	records := [][]string{}
+func (e *Export) store(buf []byte, page int, ext string) error {
+	dir := e.dir()
 
-	match := tdRegexp.FindAllStringSubmatch(*buf, -1)
-
-	if len(match) > 0 {
-		record := []string{}
-		for i, v := range match {
-			idx := i + 1
-			val := aRegexp.ReplaceAllString(strings.TrimSpace(v[1]), "")
-			//e.logger.Printf("%s %s %+v", "[DEBUG] ", "Trim After", val)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
 
-			record = append(record, val)
+	file := dir + "page-" + strconv.Itoa(page) + ext
 
-			if idx%e.opts.column == 0 {
-				records = append(records, record)
-				record = []string{}
-			}
-		}
+	if err := ioutil.WriteFile(file, buf, 0755); err != nil {
+		return err
 	}
 
-	//e.logger.Printf("match: %v", records)
+	return nil
+}
 
-	return records, nil
+// capture renders a page once for both the table and the screenshot, so the
+// screenshot always shows the rows that were parsed.
+func (e *Export) capture(url string) (fetcher.Page, error) {
+	return e.opts.fetcher.Fetch(fetcher.Request{
+		URL:      url,
+		Selector: e.opts.selectors.Css(selector.TradeTable),
+		Screen:   e.opts.screen,
+		Document: e.opts.archive,
+	})
 }
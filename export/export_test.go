@@ -0,0 +1,451 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/HarryBird/lantouzi-export/export"
+	"github.com/HarryBird/lantouzi-export/fetcher"
+	"github.com/HarryBird/lantouzi-export/ltztest"
+)
+
+func chdir(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func newExport(t *testing.T, srv *ltztest.Server, name, url string, opts ...export.Option) *export.Export {
+	f, err := fetcher.NewHTTP(nil, srv.Cookies())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return export.New(append([]export.Option{
+		export.WithCookies(srv.Cookies()),
+		export.WithFetcher(f),
+		export.WithUrl(url),
+		export.WithName(name),
+		export.WithParse(true),
+		export.WithDelay(0),
+	}, opts...)...)
+}
+
+func tradeUrl(t *testing.T, srv *ltztest.Server, kind int) string {
+	url, err := srv.Site().TradeUrl(kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return url
+}
+
+func readRecords(t *testing.T, name string) [][]string {
+	f, err := os.Open("./lantouzi/流水/" + name + "/record.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rows
+}
+
+func TestRunAll(t *testing.T) {
+	chdir(t)
+
+	trades := ltztest.Trades(23)
+	srv := ltztest.NewServer(trades, nil)
+	defer srv.Close()
+
+	e := newExport(t, srv, "全部", tradeUrl(t, srv, 0))
+	if err := e.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	txs := e.Transactions()
+	if len(txs) != len(trades) {
+		t.Fatalf("got %d transactions, want %d", len(txs), len(trades))
+	}
+
+	if !e.Schema().Balance() {
+		t.Errorf("schema %v misses the balance column", e.Schema().Columns)
+	}
+
+	if txs[0].SignedString() != trades[0].Amount || txs[0].Balance.String() != trades[0].Balance {
+		t.Errorf("first transaction %+v does not match %+v", txs[0], trades[0])
+	}
+
+	if txs[22].Page != 3 || txs[22].Row != 3 {
+		t.Errorf("last transaction from page %d row %d, want page 3 row 3", txs[22].Page, txs[22].Row)
+	}
+
+	if breaks := e.Breaks(); len(breaks) != 0 {
+		t.Errorf("unexpected balance breaks %v", breaks)
+	}
+
+	rows := readRecords(t, "全部")
+	if len(rows) != len(trades)+1 {
+		t.Fatalf("got %d csv rows, want %d", len(rows), len(trades)+1)
+	}
+
+	if rows[1][0] != trades[0].Amount || rows[1][3] != trades[0].Time {
+		t.Errorf("csv row %v does not match %+v", rows[1], trades[0])
+	}
+}
+
+func TestRunBalanceBreak(t *testing.T) {
+	chdir(t)
+
+	// a row missing from the site breaks the chain at the row above it
+	trades := ltztest.Trades(23)
+	trades = append(trades[:12:12], trades[13:]...)
+	srv := ltztest.NewServer(trades, nil)
+	defer srv.Close()
+
+	// verified by default
+	e := newExport(t, srv, "全部", tradeUrl(t, srv, 0))
+	if err := e.Run(); err == nil {
+		t.Fatal("Run passed with a broken balance chain")
+	}
+
+	breaks := e.Breaks()
+	if len(breaks) != 1 {
+		t.Fatalf("got %d breaks, want 1: %v", len(breaks), breaks)
+	}
+
+	if b := breaks[0]; b.Tx.Page != 2 || b.Tx.Row != 2 || b.Prev.Page != 2 || b.Prev.Row != 3 {
+		t.Errorf("break at %v, want page 2 row 2 after page 2 row 3", b)
+	}
+
+	// the record file is still written, and verifies the same
+	_, records, err := export.Load("全部")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again := export.VerifyBalance(records); len(again) != 1 || again[0].Tx.Page != 2 || again[0].Tx.Row != 2 {
+		t.Errorf("record file breaks %v, want one at page 2 row 2", again)
+	}
+}
+
+func TestRunShifted(t *testing.T) {
+	chdir(t)
+
+	// a trade arriving after page 1 pushes its last row onto page 2
+	trades := ltztest.Trades(24)
+	srv := ltztest.NewServer(trades[1:], nil)
+	defer srv.Close()
+
+	srv.AfterTradePage = func(page int) {
+		if page == 1 {
+			srv.Trades = trades
+		}
+	}
+
+	e := newExport(t, srv, "全部", tradeUrl(t, srv, 0), export.WithVerify(true))
+	if err := e.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if dups := e.Duplicates(); len(dups) != 1 || dups[0].Page != 2 || dups[0].Row != 1 {
+		t.Fatalf("duplicates %v, want page 2 row 1", dups)
+	}
+
+	txs := e.Transactions()
+	if len(txs) != 23 {
+		t.Fatalf("got %d transactions, want 23", len(txs))
+	}
+
+	ids := map[string]bool{}
+	for _, tx := range txs {
+		if tx.ID == "" || ids[tx.ID] {
+			t.Fatalf("missing or repeated ID on %+v", tx)
+		}
+		ids[tx.ID] = true
+	}
+
+	rows := readRecords(t, "全部")
+	if id := rows[1][len(rows[1])-1]; id != txs[0].ID {
+		t.Errorf("csv ID %s, want %s", id, txs[0].ID)
+	}
+}
+
+// failOnce fails the first fetch of a page, the way a crash or a lost
+// connection stops a run part-way.
+type failOnce struct {
+	fetcher.Fetcher
+	page   string
+	failed bool
+}
+
+func (f *failOnce) Fetch(req fetcher.Request) (fetcher.Page, error) {
+	if !f.failed && strings.Contains(req.URL, "page="+f.page+"&") {
+		f.failed = true
+		return fetcher.Page{}, errors.New("connection reset")
+	}
+
+	return f.Fetcher.Fetch(req)
+}
+
+func TestRunResume(t *testing.T) {
+	// a skipped row leaves a gap in the balance chain, so no verify
+	trades := ltztest.Trades(35)
+	trades[4].Amount = "abc"
+
+	srv := ltztest.NewServer(trades, nil)
+	defer srv.Close()
+
+	chdir(t)
+
+	if err := newExport(t, srv, "全部", tradeUrl(t, srv, 0), export.WithFormats([]string{"csv", "jsonl"}), export.WithVerify(false)).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]byte{}
+	for _, ext := range []string{"csv", "jsonl"} {
+		buf, err := ioutil.ReadFile("./lantouzi/流水/全部/record." + ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[ext] = buf
+	}
+
+	chdir(t)
+
+	f, err := fetcher.NewHTTP(nil, srv.Cookies())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	broken := newExport(t, srv, "全部", tradeUrl(t, srv, 0), export.WithFormats([]string{"csv", "jsonl"}), export.WithVerify(false),
+		export.WithFetcher(&failOnce{Fetcher: f, page: "3"}))
+	if err := broken.Run(); err == nil {
+		t.Fatal("Run passed with page 3 failing")
+	}
+
+	e := newExport(t, srv, "全部", tradeUrl(t, srv, 0), export.WithFormats([]string{"csv", "jsonl"}), export.WithVerify(false), export.WithResume(true))
+	if err := e.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	for ext, buf := range want {
+		got, err := ioutil.ReadFile("./lantouzi/流水/全部/record." + ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(got, buf) {
+			t.Errorf("resumed record.%s differs from an uninterrupted run\n%s\nwant\n%s", ext, got, buf)
+		}
+	}
+
+	// the row skipped before the interruption keeps its reason
+	if skipped := e.Skipped(); len(skipped) != 1 || skipped[0].Err == nil || skipped[0].Page != 1 {
+		t.Errorf("skipped rows %v, want page 1 with its error", skipped)
+	}
+}
+
+func TestRunTwinsAcrossPages(t *testing.T) {
+	chdir(t)
+
+	// two equal 利息 rows in the same second, the last of page 1 and the first of page 2
+	trades := []ltztest.Trade{}
+	for i := 0; i < 15; i++ {
+		trades = append(trades, ltztest.Trade{Type: 4, Amount: "+1.00", Desc: "利息", Time: fmt.Sprintf("2019-01-01 10:%02d:00", 59-i)})
+	}
+	trades[10].Time = trades[9].Time
+
+	srv := ltztest.NewServer(trades[1:], nil)
+	defer srv.Close()
+
+	e := newExport(t, srv, "利息", tradeUrl(t, srv, 4))
+	if err := e.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if dups := e.Duplicates(); len(dups) != 0 {
+		t.Fatalf("twin rows dropped as duplicates %v", dups)
+	}
+
+	if n := len(e.Transactions()); n != 14 {
+		t.Fatalf("got %d transactions, want 14", n)
+	}
+
+	ids := map[string]bool{}
+	for _, tx := range e.Transactions() {
+		ids[tx.ID] = true
+	}
+	if len(ids) != 14 {
+		t.Errorf("got %d distinct IDs, want 14", len(ids))
+	}
+
+	// a row on top moves every row down a place, the IDs stay
+	srv.Trades = trades
+
+	again := newExport(t, srv, "利息", tradeUrl(t, srv, 4))
+	if err := again.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tx := range again.Transactions()[1:] {
+		if tx.ID != e.Transactions()[i].ID {
+			t.Errorf("row %d ID %s changed to %s", i, e.Transactions()[i].ID, tx.ID)
+		}
+	}
+}
+
+func TestRunCategory(t *testing.T) {
+	chdir(t)
+
+	srv := ltztest.NewServer(ltztest.Trades(12), nil)
+	defer srv.Close()
+
+	e := newExport(t, srv, "投资", tradeUrl(t, srv, 3))
+	if err := e.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Schema().Balance() {
+		t.Errorf("schema %v has a balance column", e.Schema().Columns)
+	}
+
+	for _, tx := range e.Transactions() {
+		if tx.Direction != export.Out || tx.Category != "投资" || tx.Balance != nil {
+			t.Errorf("unexpected transaction %+v", tx)
+		}
+	}
+
+	if len(e.Transactions()) != 4 {
+		t.Errorf("got %d transactions, want 4", len(e.Transactions()))
+	}
+}
+
+func TestRunColumnMismatch(t *testing.T) {
+	chdir(t)
+
+	srv := ltztest.NewServer(ltztest.Trades(3), nil)
+	defer srv.Close()
+
+	e := newExport(t, srv, "全部", tradeUrl(t, srv, 0), export.WithColumn(3))
+	if err := e.Run(); err == nil {
+		t.Fatal("expected an error for a column override disagreeing with the page")
+	}
+}
+
+func TestRunIncremental(t *testing.T) {
+	chdir(t)
+
+	trades := ltztest.Trades(25)
+	srv := ltztest.NewServer(trades[5:], nil)
+	defer srv.Close()
+
+	if err := newExport(t, srv, "全部", tradeUrl(t, srv, 0)).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Trades = trades
+
+	e := newExport(t, srv, "全部", tradeUrl(t, srv, 0), export.WithIncremental(true))
+	if err := e.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readRecords(t, "全部")
+	if len(rows) != len(trades)+1 {
+		t.Fatalf("got %d csv rows, want %d", len(rows), len(trades)+1)
+	}
+
+	for i, trade := range trades {
+		if rows[i+1][0] != trade.Amount || rows[i+1][3] != trade.Time {
+			t.Errorf("csv row %d %v does not match %+v", i+1, rows[i+1], trade)
+		}
+	}
+}
+
+func TestRunIncrementalSameTime(t *testing.T) {
+	// rows of the newest known second on both sides of the page 1 boundary
+	trades := ltztest.Trades(30)
+	for i := 9; i < 12; i++ {
+		trades[i].Time = trades[12].Time
+	}
+
+	srv := ltztest.NewServer(trades, nil)
+	defer srv.Close()
+
+	chdir(t)
+
+	if err := newExport(t, srv, "全部", tradeUrl(t, srv, 0)).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := ioutil.ReadFile("./lantouzi/流水/全部/record.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chdir(t)
+
+	srv.Trades = trades[11:]
+	if err := newExport(t, srv, "全部", tradeUrl(t, srv, 0)).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Trades = trades
+	e := newExport(t, srv, "全部", tradeUrl(t, srv, 0), export.WithIncremental(true))
+	if err := e.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile("./lantouzi/流水/全部/record.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("merged record.csv differs from a full run\n%s\nwant\n%s", got, want)
+	}
+
+	if tx := e.Transactions()[12]; tx.Page != 2 || tx.Row != 3 {
+		t.Errorf("known row 13 numbered page %d row %d, want page 2 row 3", tx.Page, tx.Row)
+	}
+
+	if breaks := e.Breaks(); len(breaks) != 0 {
+		t.Errorf("unexpected balance breaks %v", breaks)
+	}
+}
+
+func TestRunNotLoggedIn(t *testing.T) {
+	chdir(t)
+
+	srv := ltztest.NewServer(ltztest.Trades(3), nil)
+	defer srv.Close()
+
+	f, err := fetcher.NewHTTP(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := export.New(export.WithFetcher(f), export.WithUrl(tradeUrl(t, srv, 0)), export.WithName("全部"))
+	if err := e.Run(); err == nil {
+		t.Fatal("expected an error without the session cookie")
+	}
+}
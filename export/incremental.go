@@ -0,0 +1,157 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// readCSV loads a record file written by csv back into transactions.
+func (e *Export) readCSV(file string) (Schema, []Transaction, error) {
+	schema := Schema{}
+	records := []Transaction{}
+
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		return schema, records, err
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(buf, []byte("\xEF\xBB\xBF")))).ReadAll()
+	if err != nil {
+		return schema, records, errors.WithMessagef(err, "readCSV: decode fail -> %s", file)
+	}
+
+	if len(rows) == 0 {
+		return schema, records, nil
+	}
+
+	idx := map[string]int{}
+	for i, name := range rows[0] {
+		idx[name] = i
+		if name != colPage && name != colID {
+			schema.Columns = append(schema.Columns, name)
+		}
+	}
+
+	cell := func(row []string, name string) string {
+		if i, ok := idx[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	_, source := idx[colPage]
+
+	// the row within its page, records are stored in list order
+	rowNum := map[int]int{}
+
+	for i, row := range rows[1:] {
+		// without the source columns the page is where the row is listed
+		page := i/pageSize + 1
+		if source {
+			page, _ = strconv.Atoi(cell(row, colPage))
+		}
+		rowNum[page] += 1
+
+		tx, err := newTransaction(cell(row, colAmount), cell(row, colDesc), cell(row, colBalance), cell(row, colTime), e.opts.name, page)
+		if err != nil {
+			return schema, records, errors.WithMessagef(err, "readCSV: invalid line %d -> %s", i+2, file)
+		}
+
+		tx.Row = rowNum[page]
+		tx.ID = cell(row, colID)
+		records = append(records, tx)
+	}
+
+	// files written before IDs existed get them the way parse assigns them
+	if _, ok := idx[colID]; !ok {
+		assignIDs(records)
+	}
+
+	return schema, records, nil
+}
+
+// txKey identifies a transaction by its content.
+func txKey(tx Transaction) string {
+	b := ""
+	if tx.Balance != nil {
+		b = tx.Balance.String()
+	}
+
+	return strings.Join([]string{tx.Time.In(location).Format(csvTimeLayout), tx.SignedString(), tx.Description, b}, "|")
+}
+
+// known tells which fetched rows an incremental Run already has on disk.
+type known struct {
+	newest time.Time
+	// rows at the newest timestamp, counted since identical rows are legit
+	edge map[string]int
+}
+
+func newKnown(records []Transaction) *known {
+	k := &known{edge: map[string]int{}}
+
+	for _, tx := range records {
+		if tx.Time.After(k.newest) {
+			k.newest = tx.Time
+			k.edge = map[string]int{}
+		}
+
+		if tx.Time.Equal(k.newest) {
+			k.edge[txKey(tx)] += 1
+		}
+	}
+
+	return k
+}
+
+// filter drops already known rows, reporting whether any were met.
+func (k *known) filter(records []Transaction) ([]Transaction, bool) {
+	fresh := []Transaction{}
+	reached := false
+
+	for _, tx := range records {
+		if tx.Time.Before(k.newest) {
+			reached = true
+			continue
+		}
+
+		if key := txKey(tx); tx.Time.Equal(k.newest) && k.edge[key] > 0 {
+			k.edge[key] -= 1
+			reached = true
+			continue
+		}
+
+		fresh = append(fresh, tx)
+	}
+
+	return fresh, reached
+}
+
+// loadExisting reads the current record file for an incremental Run.
+func (e *Export) loadExisting() ([]Transaction, error) {
+	schema, records, err := e.readCSV(e.dir() + "record.csv")
+	if os.IsNotExist(errors.Cause(err)) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) > 0 && len(e.schema.Columns) > 0 && strings.Join(schema.Columns, ",") != strings.Join(e.schema.Columns, ",") {
+		return nil, errors.Errorf("loadExisting: record file columns %v differ from %v", schema.Columns, e.schema.Columns)
+	}
+
+	if len(e.schema.Columns) == 0 {
+		e.schema = schema
+	}
+
+	return records, nil
+}
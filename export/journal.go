@@ -0,0 +1,206 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Accounts maps transactions onto plain text accounting accounts.
+type Accounts struct {
+	// Asset is the lantouzi cash balance every transaction posts to.
+	Asset    string `mapstructure:"asset"`
+	Currency string `mapstructure:"currency"`
+	// Types maps a trade type, e.g. 充值 or 利息, to the other side of its postings.
+	Types map[string]string `mapstructure:"types"`
+	// Other takes transactions of no known type.
+	Other string `mapstructure:"other"`
+}
+
+// DefaultAccounts is a beancount and ledger friendly chart of accounts.
+func DefaultAccounts() Accounts {
+	return Accounts{
+		Asset:    "Assets:Lantouzi:Cash",
+		Currency: "CNY",
+		Types: map[string]string{
+			"充值":   "Assets:Bank:Checking",
+			"提现":   "Assets:Bank:Checking",
+			"投资":   "Assets:Lantouzi:Invested",
+			"回收本金": "Assets:Lantouzi:Invested",
+			"利息":   "Income:Lantouzi:Interest",
+			"平台奖励": "Income:Lantouzi:Reward",
+			"手续费":  "Expenses:Lantouzi:Fee",
+		},
+		Other: "Equity:Lantouzi:Uncategorized",
+	}
+}
+
+// WithDefaults fills every field and type left empty from DefaultAccounts.
+func (a Accounts) WithDefaults() Accounts {
+	d := DefaultAccounts()
+
+	if a.Asset == "" {
+		a.Asset = d.Asset
+	}
+
+	if a.Currency == "" {
+		a.Currency = d.Currency
+	}
+
+	if a.Other == "" {
+		a.Other = d.Other
+	}
+
+	types := map[string]string{}
+	for k, v := range d.Types {
+		types[k] = v
+	}
+	for k, v := range a.Types {
+		types[k] = v
+	}
+	a.Types = types
+
+	return a
+}
+
+// Account picks the counter account of a transaction, by its target name for
+// the per type targets, by its description for 全部.
+func (a Accounts) Account(tx Transaction) string {
+	if acc, ok := a.Types[tx.Category]; ok {
+		return acc
+	}
+
+	// longest first, so 回收本金 wins over a shorter type it may contain
+	names := []string{}
+	for name := range a.Types {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		if strings.Contains(tx.Description, name) {
+			return a.Types[name]
+		}
+	}
+
+	return a.Other
+}
+
+type journalStyle int
+
+const (
+	beancount journalStyle = iota
+	ledger
+)
+
+// journalWriter writes a beancount or ledger journal, oldest first, with a
+// balance assertion per day when the target shows 账户余额.
+type journalWriter struct {
+	style    journalStyle
+	accounts Accounts
+}
+
+func (j journalWriter) Ext() string {
+	if j.style == ledger {
+		return "ledger"
+	}
+
+	return "beancount"
+}
+
+// open declares every account the records use, in order of first use, so a
+// strict check of the journal accepts them. beancount opens them all on the
+// date of the first record.
+func (j journalWriter) open(w io.Writer, accounts Accounts, sorted []Transaction) {
+	if len(sorted) == 0 {
+		return
+	}
+
+	used := []string{accounts.Asset}
+	seen := map[string]bool{accounts.Asset: true}
+	for _, tx := range sorted {
+		if a := accounts.Account(tx); !seen[a] {
+			seen[a] = true
+			used = append(used, a)
+		}
+	}
+
+	date := sorted[0].Time.In(location).Format("2006-01-02")
+	for _, a := range used {
+		switch j.style {
+		case beancount:
+			fmt.Fprintf(w, "%s open %s %s\n", date, a, accounts.Currency)
+		case ledger:
+			fmt.Fprintf(w, "account %s\n", a)
+		}
+	}
+
+	fmt.Fprintln(w)
+}
+
+// chronological orders records oldest first. The site lists newest first, so
+// reversing before a stable sort keeps rows of the same second in site order.
+func chronological(records []Transaction) []Transaction {
+	sorted := make([]Transaction, len(records))
+	for i, r := range records {
+		sorted[len(records)-1-i] = r
+	}
+
+	sort.SliceStable(sorted, func(i, k int) bool {
+		return sorted[i].Time.Before(sorted[k].Time)
+	})
+
+	return sorted
+}
+
+func (j journalWriter) Write(w io.Writer, schema Schema, records []Transaction) error {
+	accounts := j.accounts.WithDefaults()
+	bw := bufio.NewWriter(w)
+	sorted := chronological(records)
+
+	j.open(bw, accounts, sorted)
+
+	for i, tx := range sorted {
+		t := tx.Time.In(location)
+		amount := tx.Signed().String() + " " + accounts.Currency
+		counter := (-tx.Signed()).String() + " " + accounts.Currency
+
+		// the last transaction of a day carries its closing balance
+		closing := schema.Balance() && tx.Balance != nil &&
+			(i == len(sorted)-1 || sorted[i+1].Time.In(location).Format("2006-01-02") != t.Format("2006-01-02"))
+
+		switch j.style {
+		case beancount:
+			fmt.Fprintf(bw, "%s * \"lantouzi\" %q\n", t.Format("2006-01-02"), tx.Description)
+			fmt.Fprintf(bw, "  id: %q\n", tx.ID)
+			fmt.Fprintf(bw, "  time: %q\n", t.Format("15:04:05"))
+			fmt.Fprintf(bw, "  %s  %s\n", accounts.Asset, amount)
+			fmt.Fprintf(bw, "  %s  %s\n\n", accounts.Account(tx), counter)
+
+			if closing {
+				// beancount checks a balance at the start of its date
+				fmt.Fprintf(bw, "%s balance %s  %s %s\n\n", t.AddDate(0, 0, 1).Format("2006-01-02"), accounts.Asset, tx.Balance.String(), accounts.Currency)
+			}
+		case ledger:
+			assert := ""
+			if closing {
+				assert = " = " + tx.Balance.String() + " " + accounts.Currency
+			}
+
+			fmt.Fprintf(bw, "%s * %s\n", t.Format("2006/01/02"), strings.Join(strings.Fields(tx.Description), " "))
+			fmt.Fprintf(bw, "    ; id: %s\n", tx.ID)
+			fmt.Fprintf(bw, "    ; time: %s\n", t.Format("15:04:05"))
+			fmt.Fprintf(bw, "    %s  %s%s\n", accounts.Asset, amount, assert)
+			fmt.Fprintf(bw, "    %s  %s\n\n", accounts.Account(tx), counter)
+		}
+	}
+
+	return bw.Flush()
+}
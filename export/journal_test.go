@@ -0,0 +1,85 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBeancountWriter(t *testing.T) {
+	schema, records := sample(t)
+
+	buf := &bytes.Buffer{}
+	if err := (journalWriter{style: beancount}).Write(buf, schema, records); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `2019-03-01 open Assets:Lantouzi:Cash CNY
+2019-03-01 open Assets:Bank:Checking CNY
+2019-03-01 open Assets:Lantouzi:Invested CNY
+
+2019-03-01 * "lantouzi" "充值"
+  id: "` + records[1].ID + `"
+  time: "10:00:00"
+  Assets:Lantouzi:Cash  100.00 CNY
+  Assets:Bank:Checking  -100.00 CNY
+
+2019-03-02 balance Assets:Lantouzi:Cash  100.00 CNY
+
+2019-03-04 * "lantouzi" "投资"
+  id: "` + records[0].ID + `"
+  time: "05:06:07"
+  Assets:Lantouzi:Cash  -12.30 CNY
+  Assets:Lantouzi:Invested  12.30 CNY
+
+2019-03-05 balance Assets:Lantouzi:Cash  87.70 CNY
+
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestLedgerWriter(t *testing.T) {
+	schema, records := sample(t)
+
+	// no 账户余额, no assertions
+	schema = Schema{Columns: []string{colAmount, colDesc, colTime}}
+	accounts := Accounts{Types: map[string]string{"投资": "Assets:P2P"}}
+
+	buf := &bytes.Buffer{}
+	if err := (journalWriter{style: ledger, accounts: accounts}).Write(buf, schema, records[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `account Assets:Lantouzi:Cash
+account Assets:P2P
+
+2019/03/04 * 投资
+    ; id: ` + records[0].ID + `
+    ; time: 05:06:07
+    Assets:Lantouzi:Cash  -12.30 CNY
+    Assets:P2P  12.30 CNY
+
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestAccount(t *testing.T) {
+	a := DefaultAccounts()
+
+	for desc, want := range map[string]string{
+		"回收本金 项目A": "Assets:Lantouzi:Invested",
+		"利息":       "Income:Lantouzi:Interest",
+		"未知":       "Equity:Lantouzi:Uncategorized",
+	} {
+		if got := a.Account(Transaction{Description: desc, Category: "全部"}); got != want {
+			t.Errorf("account of %s is %s, want %s", desc, got, want)
+		}
+	}
+
+	if got := a.Account(Transaction{Description: "未知", Category: "手续费"}); got != "Expenses:Lantouzi:Fee" {
+		t.Errorf("account of category 手续费 is %s", got)
+	}
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// jsonRecord is the shape of a transaction in json and jsonl output. Money is
+// kept as strings in yuan so no consumer rounds it through a float.
+type jsonRecord struct {
+	ID          string  `json:"id"`
+	Amount      string  `json:"amount"`
+	Direction   string  `json:"direction"`
+	Description string  `json:"description"`
+	Balance     *string `json:"balance,omitempty"`
+	Time        string  `json:"time"`
+	Category    string  `json:"category"`
+	Page        int     `json:"page"`
+	Row         int     `json:"row"`
+}
+
+func newJSONRecord(tx Transaction) jsonRecord {
+	r := jsonRecord{
+		ID:          tx.ID,
+		Amount:      tx.Signed().String(),
+		Direction:   tx.Direction.String(),
+		Description: tx.Description,
+		Time:        tx.Time.In(location).Format(time.RFC3339),
+		Category:    tx.Category,
+		Page:        tx.Page,
+		Row:         tx.Row,
+	}
+
+	if tx.Balance != nil {
+		b := tx.Balance.String()
+		r.Balance = &b
+	}
+
+	return r
+}
+
+type jsonWriter struct{}
+
+func (jsonWriter) Ext() string {
+	return "json"
+}
+
+func (jsonWriter) Write(out io.Writer, schema Schema, records []Transaction) error {
+	list := make([]jsonRecord, 0, len(records))
+	for _, tx := range records {
+		list = append(list, newJSONRecord(tx))
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(list)
+}
+
+// jsonlWriter writes one transaction per line, for jq and friends.
+type jsonlWriter struct{}
+
+func (jsonlWriter) Ext() string {
+	return "jsonl"
+}
+
+func (jsonlWriter) Write(out io.Writer, schema Schema, records []Transaction) error {
+	enc := json.NewEncoder(out)
+
+	for _, tx := range records {
+		if err := enc.Encode(newJSONRecord(tx)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
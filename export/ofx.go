@@ -0,0 +1,158 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" +
+		`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+	ofxBank    = "LANTOUZI"
+	ofxAccount = "lantouzi"
+	// ofxNameLen is the NAME limit of the OFX spec, longer descriptions go to MEMO
+	ofxNameLen = 32
+)
+
+// ofxTime renders an OFX datetime with the Shanghai offset.
+func ofxTime(t time.Time) string {
+	return t.In(location).Format("20060102150405") + ".000[+8:CST]"
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	FITID  string `xml:"FITID"`
+	Name   string `xml:"NAME"`
+	Memo   string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	Signon  struct {
+		Status   ofxStatus `xml:"STATUS"`
+		Server   string    `xml:"DTSERVER"`
+		Language string    `xml:"LANGUAGE"`
+	} `xml:"SIGNONMSGSRSV1>SONRS"`
+	Statement struct {
+		UID       string           `xml:"TRNUID"`
+		Status    ofxStatus        `xml:"STATUS"`
+		Currency  string           `xml:"STMTRS>CURDEF"`
+		BankID    string           `xml:"STMTRS>BANKACCTFROM>BANKID"`
+		AccountID string           `xml:"STMTRS>BANKACCTFROM>ACCTID"`
+		Type      string           `xml:"STMTRS>BANKACCTFROM>ACCTTYPE"`
+		Start     string           `xml:"STMTRS>BANKTRANLIST>DTSTART"`
+		End       string           `xml:"STMTRS>BANKTRANLIST>DTEND"`
+		List      []ofxTransaction `xml:"STMTRS>BANKTRANLIST>STMTTRN"`
+		// Balance is only known on targets showing 账户余额
+		Balance *ofxBalance `xml:"STMTRS>LEDGERBAL,omitempty"`
+	} `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+// ofxWriter writes an OFX 2.2 bank statement.
+type ofxWriter struct{}
+
+func (ofxWriter) Ext() string {
+	return "ofx"
+}
+
+func (ofxWriter) Write(w io.Writer, schema Schema, records []Transaction) error {
+	doc := ofxDocument{}
+	doc.Signon.Status = ofxStatus{Code: 0, Severity: "INFO"}
+	doc.Signon.Server = ofxTime(time.Now())
+	doc.Signon.Language = "CHI"
+
+	st := &doc.Statement
+	st.UID = "0"
+	st.Status = ofxStatus{Code: 0, Severity: "INFO"}
+	st.Currency, st.BankID, st.AccountID, st.Type = "CNY", ofxBank, ofxAccount, "SAVINGS"
+	st.List = []ofxTransaction{}
+
+	var first, last time.Time
+
+	for _, r := range records {
+		if first.IsZero() || r.Time.Before(first) {
+			first = r.Time
+		}
+		if r.Time.After(last) {
+			last = r.Time
+		}
+
+		tx := ofxTransaction{
+			Type:   "CREDIT",
+			Posted: ofxTime(r.Time),
+			Amount: r.Signed().String(),
+			FITID:  r.ID,
+			Name:   r.Description,
+		}
+
+		if r.Direction == Out {
+			tx.Type = "DEBIT"
+		}
+
+		if name := []rune(r.Description); len(name) > ofxNameLen {
+			tx.Name, tx.Memo = string(name[:ofxNameLen]), r.Description
+		}
+
+		st.List = append(st.List, tx)
+	}
+
+	if len(records) > 0 {
+		st.Start, st.End = ofxTime(first), ofxTime(last)
+
+		// records are newest first, the first one carries the closing balance
+		if schema.Balance() && records[0].Balance != nil {
+			st.Balance = &ofxBalance{Amount: records[0].Balance.String(), AsOf: ofxTime(records[0].Time)}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString(ofxHeader)
+
+	enc := xml.NewEncoder(bw)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(bw)
+
+	return bw.Flush()
+}
+
+// qifWriter writes a QIF bank register, the ID goes into the N reference field.
+type qifWriter struct{}
+
+func (qifWriter) Ext() string {
+	return "qif"
+}
+
+func (qifWriter) Write(w io.Writer, schema Schema, records []Transaction) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("!Type:Bank\n")
+	for _, r := range records {
+		fmt.Fprintf(bw, "D%s\n", r.Time.In(location).Format("01/02/2006"))
+		fmt.Fprintf(bw, "T%s\n", r.Signed().String())
+		fmt.Fprintf(bw, "N%s\n", r.ID)
+		fmt.Fprintf(bw, "P%s\n", r.Description)
+		fmt.Fprintf(bw, "M%s %s\n", r.Category, r.Time.In(location).Format(csvTimeLayout))
+		bw.WriteString("^\n")
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,59 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestOFXWriter(t *testing.T) {
+	schema, records := sample(t)
+
+	buf := &bytes.Buffer{}
+	if err := (ofxWriter{}).Write(buf, schema, records); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(buf.String(), "<?xml") || !strings.Contains(buf.String(), `OFXHEADER="200"`) {
+		t.Errorf("missing ofx header\n%s", buf.String())
+	}
+
+	doc := ofxDocument{}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	list := doc.Statement.List
+	if len(list) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(list))
+	}
+
+	if list[0].Type != "DEBIT" || list[0].Amount != "-12.30" || list[0].Posted != "20190304050607.000[+8:CST]" {
+		t.Errorf("unexpected transaction %+v", list[0])
+	}
+
+	if b := doc.Statement.Balance; b == nil || b.Amount != "87.70" {
+		t.Errorf("unexpected ledger balance %+v", b)
+	}
+
+	for i, tx := range list {
+		if tx.FITID != records[i].ID {
+			t.Errorf("FITID %s, want the record ID %s", tx.FITID, records[i].ID)
+		}
+	}
+}
+
+func TestQIFWriter(t *testing.T) {
+	schema, records := sample(t)
+
+	buf := &bytes.Buffer{}
+	if err := (qifWriter{}).Write(buf, schema, records); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "!Type:Bank\nD03/04/2019\nT-12.30\nN" + records[0].ID + "\nP投资\nM全部 2019-03-04 05:06:07\n^\n"
+	if !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("got\n%s\nwant prefix\n%s", buf.String(), want)
+	}
+}
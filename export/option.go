@@ -1,5 +1,13 @@
 package export
 
+import (
+	"time"
+
+	"github.com/HarryBird/lantouzi-export/browser"
+	"github.com/HarryBird/lantouzi-export/fetcher"
+	"github.com/HarryBird/lantouzi-export/selector"
+)
+
 type Option func(*options)
 
 type options struct {
@@ -9,6 +17,22 @@ type options struct {
 	screen  bool
 	parse   bool
 	column  int
+	resume  bool
+	// incremental stops paging at rows already in the record file
+	incremental bool
+	fetcher     fetcher.Fetcher
+	// archive keeps the raw page html next to the screenshots
+	archive   bool
+	selectors selector.Profile
+	formats   []string
+	// accounts maps transactions for the beancount and ledger formats
+	accounts Accounts
+	// verify fails Run when the balance chain is broken
+	verify bool
+	// source adds a 页码 column to record.csv
+	source bool
+	// delay is the pause between two trade list pages
+	delay time.Duration
 }
 
 func WithCookies(cookies []map[string]interface{}) Option {
@@ -46,3 +70,81 @@ func WithColumn(column int) Option {
 		o.column = column
 	}
 }
+
+func WithResume(resume bool) Option {
+	return func(o *options) {
+		o.resume = resume
+	}
+}
+
+func WithIncremental(incremental bool) Option {
+	return func(o *options) {
+		o.incremental = incremental
+	}
+}
+
+// WithBrowser renders pages in a shared browser session.
+func WithBrowser(b *browser.Browser) Option {
+	return func(o *options) {
+		o.fetcher = fetcher.NewChrome(b)
+	}
+}
+
+// WithFetcher replaces how pages are rendered, Run starts its own browser otherwise.
+func WithFetcher(f fetcher.Fetcher) Option {
+	return func(o *options) {
+		o.fetcher = f
+	}
+}
+
+func WithArchive(archive bool) Option {
+	return func(o *options) {
+		o.archive = archive
+	}
+}
+
+// WithSelectors reads pages with another selector profile than selector.Default.
+func WithSelectors(p selector.Profile) Option {
+	return func(o *options) {
+		o.selectors = p
+	}
+}
+
+// WithFormats picks the record files Run writes, see Formats, csv by default.
+func WithFormats(formats []string) Option {
+	return func(o *options) {
+		if len(formats) > 0 {
+			o.formats = formats
+		}
+	}
+}
+
+// WithAccounts sets the accounts of the beancount and ledger formats, see DefaultAccounts.
+func WithAccounts(a Accounts) Option {
+	return func(o *options) {
+		o.accounts = a.WithDefaults()
+	}
+}
+
+// WithVerify makes Run fail when the balance chain is broken, the default,
+// it only logs the breaks when turned off.
+func WithVerify(verify bool) Option {
+	return func(o *options) {
+		o.verify = verify
+	}
+}
+
+// WithSourceColumns adds 页码 to the columns of record.csv, which otherwise
+// keeps the trade list's own columns followed by 流水号.
+func WithSourceColumns(source bool) Option {
+	return func(o *options) {
+		o.source = source
+	}
+}
+
+// WithDelay pauses between two trade list pages, 500ms by default.
+func WithDelay(delay time.Duration) Option {
+	return func(o *options) {
+		o.delay = delay
+	}
+}
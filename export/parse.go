@@ -0,0 +1,244 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/pkg/errors"
+)
+
+const (
+	colAmount  = "交易金额"
+	colDesc    = "说明"
+	colBalance = "账户余额"
+	colTime    = "交易时间"
+)
+
+// RowError describes a table row that could not be turned into a Transaction.
+type RowError struct {
+	Page  int
+	Row   int
+	Cells []string
+	Err   error
+}
+
+func (r RowError) Error() string {
+	return fmt.Sprintf("page %d row %d %v: %v", r.Page, r.Row, r.Cells, r.Err)
+}
+
+// rowErrorJSON is a RowError in a checkpoint, Err kept as its text.
+type rowErrorJSON struct {
+	Page  int
+	Row   int
+	Cells []string
+	Err   string
+}
+
+func (r RowError) MarshalJSON() ([]byte, error) {
+	v := rowErrorJSON{Page: r.Page, Row: r.Row, Cells: r.Cells}
+	if r.Err != nil {
+		v.Err = r.Err.Error()
+	}
+
+	return json.Marshal(v)
+}
+
+func (r *RowError) UnmarshalJSON(buf []byte) error {
+	v := rowErrorJSON{}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return err
+	}
+
+	*r = RowError{Page: v.Page, Row: v.Row, Cells: v.Cells}
+	if v.Err != "" {
+		r.Err = errors.New(v.Err)
+	}
+
+	return nil
+}
+
+// table is the trade list as read from the DOM, cells already expanded by colspan.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func readTable(buf string) (table, error) {
+	t := table{}
+
+	// InnerHTML strips the <table> element itself, and html parsing drops
+	// bare <tr> nodes outside of one.
+	dom, err := goquery.NewDocumentFromReader(strings.NewReader("<table>" + buf + "</table>"))
+	if err != nil {
+		return t, errors.WithMessage(err, "readTable: load html to dom fail")
+	}
+
+	// only the rows and cells of this table, not of a table nested in a cell
+	rows := dom.Find("table").First().ChildrenFiltered("thead, tbody, tfoot").ChildrenFiltered("tr")
+
+	rows.Each(func(i int, trNode *goquery.Selection) {
+		if th := trNode.ChildrenFiltered("th"); th.Length() > 0 {
+			if len(t.header) == 0 {
+				t.header = cells(th)
+			}
+			return
+		}
+
+		if td := trNode.ChildrenFiltered("td"); td.Length() > 0 {
+			t.rows = append(t.rows, cells(td))
+		}
+	})
+
+	return t, nil
+}
+
+func cells(nodes *goquery.Selection) []string {
+	values := []string{}
+
+	nodes.Each(func(i int, node *goquery.Selection) {
+		val := strings.Join(strings.Fields(node.Text()), " ")
+		values = append(values, val)
+
+		if span, err := strconv.Atoi(node.AttrOr("colspan", "1")); err == nil {
+			for ; span > 1; span-- {
+				values = append(values, "")
+			}
+		}
+	})
+
+	return values
+}
+
+// Schema is the set of columns a trade list page shows, in page order.
+type Schema struct {
+	Columns []string
+}
+
+// Has reports whether the page shows the named column.
+func (s Schema) Has(name string) bool {
+	for _, c := range s.Columns {
+		if c == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Balance reports whether the page shows 账户余额.
+func (s Schema) Balance() bool {
+	return s.Has(colBalance)
+}
+
+// detectSchema builds the schema from the page header, falling back to the
+// configured column count for pages without one. A non zero column count
+// disagreeing with the header is an error.
+func detectSchema(header []string, column int) (Schema, error) {
+	if len(header) == 0 {
+		switch column {
+		case 4:
+			return Schema{Columns: []string{colAmount, colDesc, colBalance, colTime}}, nil
+		case 3:
+			return Schema{Columns: []string{colAmount, colDesc, colTime}}, nil
+		}
+
+		return Schema{}, errors.Errorf("detectSchema: no table header and no valid column override (%d)", column)
+	}
+
+	s := Schema{}
+	for _, name := range header {
+		s.Columns = append(s.Columns, strings.TrimSpace(name))
+	}
+
+	for _, name := range []string{colAmount, colDesc, colTime} {
+		if !s.Has(name) {
+			return s, errors.Errorf("detectSchema: missing column %s in header %v", name, header)
+		}
+	}
+
+	if column != 0 && column != len(s.Columns) {
+		return s, errors.Errorf("detectSchema: column is set to %d but page shows %d columns %v", column, len(s.Columns), s.Columns)
+	}
+
+	return s, nil
+}
+
+func (e *Export) parse(buf *string, page int) ([]Transaction, []RowError, error) {
+	records := []Transaction{}
+	invalid := []RowError{}
+
+	t, err := readTable(*buf)
+	if err != nil {
+		return records, invalid, err
+	}
+
+	schema, err := detectSchema(t.header, e.opts.column)
+	if err != nil {
+		// an empty page past the last one may come without a header
+		if len(t.header) == 0 && len(t.rows) <= 1 {
+			return records, invalid, nil
+		}
+		return records, invalid, err
+	}
+
+	if len(e.schema.Columns) == 0 {
+		e.logger.Printf("%s %s %v", "[INFO] ", "detect columns -> ", schema.Columns)
+		e.schema = schema
+	} else if strings.Join(schema.Columns, ",") != strings.Join(e.schema.Columns, ",") {
+		return records, invalid, errors.Errorf("parse: page %d columns %v differ from %v", page, schema.Columns, e.schema.Columns)
+	}
+
+	idx := map[string]int{}
+	for i, name := range schema.Columns {
+		idx[name] = i
+	}
+
+	for i, row := range t.rows {
+		// the "no data" placeholder is a single cell spanning the whole table
+		if len(row) > 0 && strings.Join(row[1:], "") == "" && len(t.rows) == 1 {
+			continue
+		}
+
+		tx, err := e.transaction(row, idx, page, i+1)
+		if err != nil {
+			invalid = append(invalid, RowError{Page: page, Row: i + 1, Cells: row, Err: err})
+			continue
+		}
+
+		records = append(records, tx)
+	}
+
+	return records, invalid, nil
+}
+
+func (e *Export) transaction(row []string, idx map[string]int, page, num int) (Transaction, error) {
+	cell := func(name string) (string, error) {
+		i, ok := idx[name]
+		if !ok {
+			return "", nil
+		}
+
+		if i >= len(row) {
+			return "", errors.Errorf("transaction: missing cell %s", name)
+		}
+
+		return row[i], nil
+	}
+
+	values := map[string]string{}
+	for _, name := range []string{colAmount, colDesc, colBalance, colTime} {
+		v, err := cell(name)
+		if err != nil {
+			return Transaction{}, err
+		}
+		values[name] = v
+	}
+
+	tx, err := newTransaction(values[colAmount], values[colDesc], values[colBalance], values[colTime], e.opts.name, page)
+	tx.Row = num
+
+	return tx, err
+}
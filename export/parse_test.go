@@ -0,0 +1,32 @@
+package export
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadTable(t *testing.T) {
+	// a cell holding a table of its own, the way a tooltip can
+	buf := `<thead><tr><th>交易金额</th><th>说明</th><th>交易时间</th></tr></thead>
+<tbody>
+<tr><td>+100.00</td><td>充值<table><tr><th>渠道</th></tr><tr><td>网银</td><td>工行</td></tr></table></td><td>2019-03-01 10:00:00</td></tr>
+<tr><td>-12.30</td><td colspan="2">投资</td></tr>
+</tbody>`
+
+	tbl, err := readTable(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"交易金额", "说明", "交易时间"}; !reflect.DeepEqual(tbl.header, want) {
+		t.Errorf("header %q, want %q", tbl.header, want)
+	}
+
+	want := [][]string{
+		{"+100.00", "充值渠道网银工行", "2019-03-01 10:00:00"},
+		{"-12.30", "投资", ""},
+	}
+	if !reflect.DeepEqual(tbl.rows, want) {
+		t.Errorf("rows %q, want %q", tbl.rows, want)
+	}
+}
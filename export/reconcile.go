@@ -0,0 +1,144 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Total sums the records of one target.
+type Total struct {
+	Category string
+	Count    int
+	In       Amount
+	Out      Amount
+	First    time.Time
+	Last     time.Time
+}
+
+// Net is income minus spending.
+func (t Total) Net() Amount {
+	return t.In - t.Out
+}
+
+// Sum totals the records of the named target.
+func Sum(name string, records []Transaction) Total {
+	t := Total{Category: name, Count: len(records)}
+
+	for _, tx := range records {
+		if tx.Direction == Out {
+			t.Out += tx.Amount
+		} else {
+			t.In += tx.Amount
+		}
+
+		if t.First.IsZero() || tx.Time.Before(t.First) {
+			t.First = tx.Time
+		}
+
+		if tx.Time.After(t.Last) {
+			t.Last = tx.Time
+		}
+	}
+
+	return t
+}
+
+// Reconciliation compares the 全部 target with the union of the type
+// filtered targets, rows are matched by time, amount and description.
+type Reconciliation struct {
+	All    Total
+	Totals []Total
+	// Union totals every category together, equal to All when nothing is missing
+	Union Total
+	// Uncategorized rows are in 全部 but in no category
+	Uncategorized []Transaction
+	// Unlisted rows are in a category but not in 全部
+	Unlisted []Transaction
+}
+
+// OK reports whether every row matched.
+func (r Reconciliation) OK() bool {
+	return len(r.Uncategorized) == 0 && len(r.Unlisted) == 0
+}
+
+func matchKey(tx Transaction) string {
+	return strings.Join([]string{tx.Time.In(location).Format(csvTimeLayout), tx.SignedString(), tx.Description}, "|")
+}
+
+// Reconcile matches the rows of all against the rows of categories, keyed by target name.
+func Reconcile(allName string, all []Transaction, categories map[string][]Transaction) Reconciliation {
+	r := Reconciliation{All: Sum(allName, all), Uncategorized: []Transaction{}, Unlisted: []Transaction{}}
+
+	names := []string{}
+	for name := range categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// identical rows are legit, so rows are counted rather than set
+	pending := map[string][]Transaction{}
+	for _, tx := range all {
+		pending[matchKey(tx)] = append(pending[matchKey(tx)], tx)
+	}
+
+	union := []Transaction{}
+	for _, name := range names {
+		records := categories[name]
+		r.Totals = append(r.Totals, Sum(name, records))
+		union = append(union, records...)
+
+		for _, tx := range records {
+			key := matchKey(tx)
+			if len(pending[key]) == 0 {
+				r.Unlisted = append(r.Unlisted, tx)
+				continue
+			}
+			pending[key] = pending[key][1:]
+		}
+	}
+
+	r.Union = Sum("合计", union)
+
+	for _, tx := range all {
+		key := matchKey(tx)
+		if len(pending[key]) > 0 {
+			r.Uncategorized = append(r.Uncategorized, pending[key][0])
+			pending[key] = pending[key][1:]
+		}
+	}
+
+	return r
+}
+
+// WriteReport renders the reconciliation as a plain text report.
+func (r Reconciliation) WriteReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "类别\t笔数\t收入\t支出\t净额\t")
+	for _, t := range append(append([]Total{}, r.Totals...), r.Union, r.All) {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t\n", t.Category, t.Count, t.In, t.Out, t.Net())
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	rows := func(title string, records []Transaction) {
+		fmt.Fprintf(w, "\n%s: %d\n", title, len(records))
+		for _, tx := range records {
+			fmt.Fprintf(w, "  %s page %d row %d %s %s %s\n", tx.Category, tx.Page, tx.Row,
+				tx.Time.In(location).Format(csvTimeLayout), tx.SignedString(), tx.Description)
+		}
+	}
+
+	rows("in "+r.All.Category+" but in no category", r.Uncategorized)
+	rows("in a category but not in "+r.All.Category, r.Unlisted)
+
+	_, err := fmt.Fprintln(w)
+
+	return err
+}
@@ -0,0 +1,49 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	_, all := sample(t)
+
+	invest := all[0]
+	invest.Category = "投资"
+
+	stray, err := newTransaction("+1.00", "利息", "", "2019-03-05 00:00:00", "利息", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := Reconcile("全部", all, map[string][]Transaction{
+		"投资": {invest},
+		"利息": {stray},
+	})
+
+	if r.OK() {
+		t.Fatal("reconciliation passed with missing rows")
+	}
+
+	if len(r.Uncategorized) != 1 || r.Uncategorized[0].Description != "充值" {
+		t.Errorf("uncategorized %v, want the 充值 row", r.Uncategorized)
+	}
+
+	if len(r.Unlisted) != 1 || r.Unlisted[0].Category != "利息" {
+		t.Errorf("unlisted %v, want the 利息 row", r.Unlisted)
+	}
+
+	if r.All.In != 10000 || r.All.Out != 1230 || r.Union.Count != 2 || r.Union.Net() != -1130 {
+		t.Errorf("unexpected totals all %+v union %+v", r.All, r.Union)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := r.WriteReport(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "全部 page 1 row 0 2019-03-01 10:00:00 +100.00 充值") {
+		t.Errorf("report misses the uncategorized row\n%s", buf.String())
+	}
+}
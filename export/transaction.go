@@ -0,0 +1,220 @@
+package export
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	timeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+	location    = loadLocation()
+)
+
+func loadLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		return time.FixedZone("CST", 8*60*60)
+	}
+
+	return loc
+}
+
+// Direction tells whether a transaction moves money into or out of the account.
+type Direction int
+
+const (
+	In Direction = iota + 1
+	Out
+)
+
+func (d Direction) String() string {
+	switch d {
+	case In:
+		return "in"
+	case Out:
+		return "out"
+	}
+
+	return "unknown"
+}
+
+// Amount is a money value in fen (0.01 CNY), so sums never lose precision.
+type Amount int64
+
+// ParseAmount parses strings like "+1,234.56", "-0.5" or "100.00元".
+func ParseAmount(s string) (Amount, error) {
+	v := strings.TrimSpace(s)
+	v = strings.TrimSuffix(v, "元")
+	v = strings.NewReplacer(",", "", "，", "", "¥", "", "￥", "", " ", "").Replace(v)
+
+	if v == "" {
+		return 0, errors.Errorf("empty amount")
+	}
+
+	neg := false
+	switch v[0] {
+	case '-':
+		neg = true
+		v = v[1:]
+	case '+':
+		v = v[1:]
+	}
+
+	yuan, fen := v, ""
+	if i := strings.Index(v, "."); i >= 0 {
+		yuan, fen = v[:i], v[i+1:]
+	}
+
+	if len(fen) > 2 {
+		return 0, errors.Errorf("invalid amount, more than fen precision -> %s", s)
+	}
+
+	for len(fen) < 2 {
+		fen += "0"
+	}
+
+	if yuan == "" {
+		yuan = "0"
+	}
+
+	y, err := strconv.ParseInt(yuan, 10, 64)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "invalid amount -> %s", s)
+	}
+
+	f, err := strconv.ParseInt(fen, 10, 64)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "invalid amount -> %s", s)
+	}
+
+	a := Amount(y*100 + f)
+	if neg {
+		a = -a
+	}
+
+	return a, nil
+}
+
+// String renders the amount in yuan with two decimals, e.g. "-1234.56".
+func (a Amount) String() string {
+	sign := ""
+	if a < 0 {
+		sign = "-"
+		a = -a
+	}
+
+	return sign + strconv.FormatInt(int64(a)/100, 10) + "." + twoDigits(int64(a)%100)
+}
+
+func twoDigits(v int64) string {
+	if v < 10 {
+		return "0" + strconv.FormatInt(v, 10)
+	}
+
+	return strconv.FormatInt(v, 10)
+}
+
+// ParseTime parses a lantouzi.com timestamp in Asia/Shanghai.
+func ParseTime(s string) (time.Time, error) {
+	v := strings.TrimSpace(s)
+
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, v, location); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.Errorf("invalid time -> %s", s)
+}
+
+// Transaction is one row of the trade list.
+type Transaction struct {
+	// ID is stable across runs, see assignIDs.
+	ID string
+	// Amount is always positive, Direction carries the sign.
+	Amount      Amount
+	Direction   Direction
+	Description string
+	// Balance is only present on targets showing 账户余额.
+	Balance  *Amount
+	Time     time.Time
+	Category string
+	Page     int
+	Row      int
+}
+
+// Signed returns the amount with its direction applied.
+func (t Transaction) Signed() Amount {
+	if t.Direction == Out {
+		return -t.Amount
+	}
+
+	return t.Amount
+}
+
+// SignedString renders the amount the way the site does, e.g. "+100.00".
+func (t Transaction) SignedString() string {
+	if t.Direction == Out {
+		return "-" + t.Amount.String()
+	}
+
+	return "+" + t.Amount.String()
+}
+
+func newTransaction(amount, desc, balance, when, category string, page int) (Transaction, error) {
+	tx := Transaction{
+		Description: strings.TrimSpace(desc),
+		Category:    category,
+		Page:        page,
+	}
+
+	a, err := ParseAmount(amount)
+	if err != nil {
+		return tx, err
+	}
+
+	tx.Amount, tx.Direction = a, In
+	if a < 0 {
+		tx.Amount, tx.Direction = -a, Out
+	}
+
+	if balance != "" {
+		b, err := ParseAmount(balance)
+		if err != nil {
+			return tx, err
+		}
+		tx.Balance = &b
+	}
+
+	if tx.Time, err = ParseTime(when); err != nil {
+		return tx, err
+	}
+
+	return tx, nil
+}
+
+// contentHash hashes what the site shows of a row.
+func contentHash(tx Transaction) string {
+	sum := sha1.Sum([]byte(txKey(tx)))
+
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// assignIDs sets the ID of the records of a whole target, listed newest first,
+// to their content hash plus an ordinal telling identical rows apart. Ordinals
+// count from the oldest row, so rows added on top later and rows moving
+// between pages leave the IDs of the rows below unchanged.
+func assignIDs(records []Transaction) {
+	seen := map[string]int{}
+
+	for i := len(records) - 1; i >= 0; i-- {
+		key := contentHash(records[i])
+		records[i].ID = key + "-" + strconv.Itoa(seen[key])
+		seen[key] += 1
+	}
+}
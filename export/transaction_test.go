@@ -0,0 +1,68 @@
+package export
+
+import (
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Amount
+		ok   bool
+	}{
+		{"+1,234.56", 123456, true},
+		{"-0.5", -50, true},
+		{"100.00元", 10000, true},
+		{" 3 ", 300, true},
+		{"-.07", -7, true},
+		{"1.234", 0, false},
+		{"", 0, false},
+		{"abc", 0, false},
+	}
+
+	for _, c := range cases {
+		got, err := ParseAmount(c.in)
+		if (err == nil) != c.ok || got != c.want {
+			t.Errorf("ParseAmount(%q) = %v, %v, want %v ok %v", c.in, got, err, c.want, c.ok)
+		}
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	for in, want := range map[Amount]string{0: "0.00", 5: "0.05", -123456: "-1234.56", 100: "1.00"} {
+		if got := in.String(); got != want {
+			t.Errorf("Amount(%d).String() = %s, want %s", int64(in), got, want)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tm, err := ParseTime("2019-03-04 05:06:07")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, offset := tm.Zone(); offset != 8*60*60 {
+		t.Errorf("got offset %d, want Asia/Shanghai", offset)
+	}
+
+	if _, err := ParseTime("yesterday"); err == nil {
+		t.Error("expected an error for an invalid time")
+	}
+}
+
+func TestAssignIDs(t *testing.T) {
+	_, records := sample(t)
+
+	twins := []Transaction{records[0], records[0], records[1]}
+	assignIDs(twins)
+
+	if twins[0].ID == twins[1].ID || twins[0].ID[:16] != twins[1].ID[:16] {
+		t.Errorf("identical rows got IDs %s and %s, want the same hash with another ordinal", twins[0].ID, twins[1].ID)
+	}
+
+	// ordinals count from the oldest row, a row added on top keeps the others
+	if twins[1].ID != records[0].ID || twins[2].ID != records[1].ID {
+		t.Errorf("IDs changed with the rows above them: %v vs %v", twins, records)
+	}
+}
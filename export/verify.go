@@ -0,0 +1,51 @@
+package export
+
+import (
+	"fmt"
+)
+
+// Break is a row whose 账户余额 does not follow from the row before it,
+// usually a skipped page or a mis-parsed row around it.
+type Break struct {
+	Prev Transaction
+	Tx   Transaction
+	// Want is the previous balance plus the row's amount
+	Want Amount
+}
+
+func (b Break) Error() string {
+	return fmt.Sprintf("page %d row %d %s %s %s: balance %s, want %s from page %d row %d balance %s",
+		b.Tx.Page, b.Tx.Row, b.Tx.Time.In(location).Format(csvTimeLayout), b.Tx.SignedString(), b.Tx.Description,
+		b.Tx.Balance, b.Want, b.Prev.Page, b.Prev.Row, b.Prev.Balance)
+}
+
+// VerifyBalance walks the records oldest first and reports every row where
+// previous balance + amount != balance. Rows without a balance are skipped.
+func VerifyBalance(records []Transaction) []Break {
+	breaks := []Break{}
+
+	var prev *Transaction
+	for _, tx := range chronological(records) {
+		if tx.Balance == nil {
+			continue
+		}
+
+		if prev != nil {
+			if want := *prev.Balance + tx.Signed(); want != *tx.Balance {
+				breaks = append(breaks, Break{Prev: *prev, Tx: tx, Want: want})
+			}
+		}
+
+		tx := tx
+		prev = &tx
+	}
+
+	return breaks
+}
+
+// Load reads the record.csv a previous Run wrote for the named target.
+func Load(name string) (Schema, []Transaction, error) {
+	e := New(WithName(name))
+
+	return e.readCSV(e.dir() + "record.csv")
+}
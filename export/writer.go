@@ -0,0 +1,47 @@
+package export
+
+import (
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Writer renders transactions into one output format.
+type Writer interface {
+	// Ext names the output file, record.<Ext>
+	Ext() string
+	Write(w io.Writer, schema Schema, records []Transaction) error
+}
+
+var writers = map[string]Writer{
+	"csv":       csvWriter{},
+	"json":      jsonWriter{},
+	"jsonl":     jsonlWriter{},
+	"beancount": journalWriter{style: beancount},
+	"ledger":    journalWriter{style: ledger},
+	"ofx":       ofxWriter{},
+	"qif":       qifWriter{},
+}
+
+// NewWriter returns the writer of a format name, see Formats.
+func NewWriter(format string) (Writer, error) {
+	w, ok := writers[format]
+	if !ok {
+		return nil, errors.Errorf("NewWriter: unknown format %q, want one of %v", format, Formats())
+	}
+
+	return w, nil
+}
+
+// Formats lists every format name NewWriter knows.
+func Formats() []string {
+	formats := []string{}
+	for name := range writers {
+		formats = append(formats, name)
+	}
+
+	sort.Strings(formats)
+
+	return formats
+}
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func sample(t *testing.T) (Schema, []Transaction) {
+	schema := Schema{Columns: []string{colAmount, colDesc, colBalance, colTime}}
+
+	records := []Transaction{}
+	for _, row := range [][]string{
+		{"-12.30", "投资", "87.70", "2019-03-04 05:06:07"},
+		{"+100.00", "充值", "100.00", "2019-03-01 10:00:00"},
+	} {
+		tx, err := newTransaction(row[0], row[1], row[2], row[3], "全部", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, tx)
+	}
+
+	assignIDs(records)
+
+	return schema, records
+}
+
+func TestJSONLWriter(t *testing.T) {
+	schema, records := sample(t)
+
+	buf := &bytes.Buffer{}
+	if err := (jsonlWriter{}).Write(buf, schema, records); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := []jsonRecord{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		r := jsonRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, r)
+	}
+
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+
+	first := lines[0]
+	if first.Amount != "-12.30" || first.Direction != "out" || *first.Balance != "87.70" || first.Time != "2019-03-04T05:06:07+08:00" {
+		t.Errorf("unexpected record %+v", first)
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	schema, records := sample(t)
+
+	buf := &bytes.Buffer{}
+	if err := (jsonWriter{}).Write(buf, schema, records); err != nil {
+		t.Fatal(err)
+	}
+
+	list := []jsonRecord{}
+	if err := json.Unmarshal(buf.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 2 || list[1].Amount != "100.00" || list[1].Category != "全部" {
+		t.Errorf("unexpected records %+v", list)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	schema, records := sample(t)
+
+	buf := &bytes.Buffer{}
+	if err := (csvWriter{}).Write(buf, schema, records); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "\xEF\xBB\xBF交易金额,说明,账户余额,交易时间,流水号\n" +
+		"-12.30,投资,87.70,2019-03-04 05:06:07," + records[0].ID + "\n" +
+		"+100.00,充值,100.00,2019-03-01 10:00:00," + records[1].ID + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if err := (csvWriter{source: true}).Write(buf, schema, records); err != nil {
+		t.Fatal(err)
+	}
+
+	want = "\xEF\xBB\xBF交易金额,说明,账户余额,交易时间,页码,流水号\n" +
+		"-12.30,投资,87.70,2019-03-04 05:06:07,1," + records[0].ID + "\n" +
+		"+100.00,充值,100.00,2019-03-01 10:00:00,1," + records[1].ID + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("with source columns got %q, want %q", got, want)
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	if _, err := NewWriter("xml"); err == nil || !strings.Contains(err.Error(), "jsonl") {
+		t.Errorf("unexpected error for an unknown format: %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+const summarySheet = "汇总"
+
+// Sheet is one target's records in a workbook.
+type Sheet struct {
+	Name    string
+	Schema  Schema
+	Records []Transaction
+}
+
+// Sheet returns what the last Run collected, for WriteWorkbook.
+func (e *Export) Sheet() Sheet {
+	return Sheet{Name: e.opts.name, Schema: e.schema, Records: e.records}
+}
+
+type workbook struct {
+	f      *excelize.File
+	money  int
+	date   int
+	header int
+}
+
+// WriteWorkbook writes every sheet into one xlsx file, led by a summary sheet,
+// with real numbers for money and real dates for times.
+func WriteWorkbook(file string, sheets []Sheet) error {
+	wb := &workbook{f: excelize.NewFile()}
+
+	if err := wb.styles(); err != nil {
+		return errors.WithMessage(err, "WriteWorkbook: create styles fail")
+	}
+
+	wb.f.SetSheetName(wb.f.GetSheetName(0), summarySheet)
+
+	if err := wb.summary(sheets); err != nil {
+		return errors.WithMessage(err, "WriteWorkbook: write summary fail")
+	}
+
+	for _, sheet := range sheets {
+		if err := wb.sheet(sheet); err != nil {
+			return errors.WithMessagef(err, "WriteWorkbook: write sheet fail -> %s", sheet.Name)
+		}
+	}
+
+	wb.f.SetActiveSheet(0)
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	if err := wb.f.SaveAs(file); err != nil {
+		return errors.WithMessagef(err, "WriteWorkbook: save fail -> %s", file)
+	}
+
+	return nil
+}
+
+func (wb *workbook) styles() error {
+	money, date := "#,##0.00", "yyyy-mm-dd hh:mm:ss"
+	var err error
+
+	if wb.money, err = wb.f.NewStyle(&excelize.Style{CustomNumFmt: &money}); err != nil {
+		return err
+	}
+
+	if wb.date, err = wb.f.NewStyle(&excelize.Style{CustomNumFmt: &date}); err != nil {
+		return err
+	}
+
+	if wb.header, err = wb.f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// excelTime keeps the Asia/Shanghai wall clock, excel dates have no zone.
+func excelTime(t time.Time) time.Time {
+	t = t.In(location)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+func yuan(a Amount) float64 {
+	return float64(a) / 100
+}
+
+func (wb *workbook) set(sheet string, col, row int, value interface{}, style int) error {
+	cell, err := excelize.CoordinatesToCellName(col, row)
+	if err != nil {
+		return err
+	}
+
+	if err := wb.f.SetCellValue(sheet, cell, value); err != nil {
+		return err
+	}
+
+	if style != 0 {
+		return wb.f.SetCellStyle(sheet, cell, cell, style)
+	}
+
+	return nil
+}
+
+func (wb *workbook) headerRow(sheet string, header []string) error {
+	for i, name := range header {
+		if err := wb.set(sheet, i+1, 1, name, wb.header); err != nil {
+			return err
+		}
+	}
+
+	last, err := excelize.ColumnNumberToName(len(header))
+	if err != nil {
+		return err
+	}
+
+	if err := wb.f.SetColWidth(sheet, "A", last, 18); err != nil {
+		return err
+	}
+
+	return wb.f.SetPanes(sheet, `{"freeze":true,"split":false,"x_split":0,"y_split":1,"top_left_cell":"A2","active_pane":"bottomLeft"}`)
+}
+
+func (wb *workbook) sheet(sheet Sheet) error {
+	wb.f.NewSheet(sheet.Name)
+
+	if err := wb.headerRow(sheet.Name, csvHeader(sheet.Schema, true)); err != nil {
+		return err
+	}
+
+	for i, tx := range sheet.Records {
+		row := i + 2
+
+		for j, name := range sheet.Schema.Columns {
+			var value interface{}
+			style := 0
+
+			switch name {
+			case colAmount:
+				value, style = yuan(tx.Signed()), wb.money
+			case colDesc:
+				value = tx.Description
+			case colBalance:
+				if tx.Balance != nil {
+					value, style = yuan(*tx.Balance), wb.money
+				}
+			case colTime:
+				value, style = excelTime(tx.Time), wb.date
+			}
+
+			if err := wb.set(sheet.Name, j+1, row, value, style); err != nil {
+				return err
+			}
+		}
+
+		if err := wb.set(sheet.Name, len(sheet.Schema.Columns)+1, row, tx.Page, 0); err != nil {
+			return err
+		}
+
+		if err := wb.set(sheet.Name, len(sheet.Schema.Columns)+2, row, tx.ID, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (wb *workbook) summary(sheets []Sheet) error {
+	if err := wb.headerRow(summarySheet, []string{"类别", "笔数", "收入", "支出", "净额", "最早交易", "最近交易"}); err != nil {
+		return err
+	}
+
+	for i, sheet := range sheets {
+		t := Sum(sheet.Name, sheet.Records)
+
+		row := i + 2
+		values := []interface{}{t.Category, t.Count, yuan(t.In), yuan(t.Out), yuan(t.Net()), nil, nil}
+		styles := []int{0, 0, wb.money, wb.money, wb.money, 0, 0}
+
+		if t.Count > 0 {
+			values[5], values[6] = excelTime(t.First), excelTime(t.Last)
+			styles[5], styles[6] = wb.date, wb.date
+		}
+
+		for j, value := range values {
+			if err := wb.set(summarySheet, j+1, row, value, styles[j]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteWorkbook(t *testing.T) {
+	schema, records := sample(t)
+	file := filepath.Join(t.TempDir(), "out", "lantouzi.xlsx")
+
+	sheets := []Sheet{
+		{Name: "全部", Schema: schema, Records: records},
+		{Name: "充值", Schema: Schema{Columns: []string{colAmount, colDesc, colTime}}, Records: records[1:]},
+	}
+
+	if err := WriteWorkbook(file, sheets); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := excelize.OpenFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := f.GetSheetList(); len(got) != 3 || got[0] != summarySheet || got[1] != "全部" || got[2] != "充值" {
+		t.Fatalf("unexpected sheets %v", got)
+	}
+
+	// money is stored as plain numbers, the display format lives in the style
+	cases := map[string]string{
+		"A1": "交易金额",
+		"A2": "-12.3",
+		"C2": "87.7",
+		"E3": "1",
+	}
+
+	for cell, want := range cases {
+		got, err := f.GetCellValue("全部", cell)
+		if err != nil || got != want {
+			t.Errorf("全部!%s = %q, %v, want %q", cell, got, err, want)
+		}
+	}
+
+	// excelize reads dates back through float days, allow it to lose the seconds
+	if got, err := f.GetCellValue("全部", "D2"); err != nil || len(got) < 16 || got[:16] != "2019-03-04 05:06" {
+		t.Errorf("全部!D2 = %q, %v, want a date", got, err)
+	}
+
+	for cell, want := range map[string]string{"A2": "全部", "B2": "2", "C2": "100", "D2": "12.3", "E2": "87.7", "B3": "1"} {
+		got, err := f.GetCellValue(summarySheet, cell)
+		if err != nil || got != want {
+			t.Errorf("%s!%s = %q, %v, want %q", summarySheet, cell, got, err, want)
+		}
+	}
+}
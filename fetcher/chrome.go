@@ -0,0 +1,109 @@
+package fetcher
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/HarryBird/lantouzi-export/browser"
+	"github.com/chromedp/chromedp"
+	"github.com/pkg/errors"
+)
+
+// changeTimeout is how long a pager view may take to render after a click.
+const changeTimeout = 15 * time.Second
+
+// Chrome renders pages live in a shared browser session.
+type Chrome struct {
+	browser *browser.Browser
+}
+
+func NewChrome(b *browser.Browser) *Chrome {
+	return &Chrome{browser: b}
+}
+
+// Fetch collects everything asked for from a single navigation.
+func (c *Chrome) Fetch(req Request) (Page, error) {
+	p := Page{}
+	actions := []chromedp.Action{}
+
+	if req.Wait != "" {
+		actions = append(actions, chromedp.WaitVisible(req.Wait, chromedp.ByQuery))
+	}
+
+	if req.Selector != "" {
+		actions = append(actions, chromedp.InnerHTML(req.Selector, &p.HTML, chromedp.NodeVisible, chromedp.ByQuery))
+	}
+
+	if req.Document {
+		actions = append(actions, chromedp.OuterHTML("html", &p.Document, chromedp.ByQuery))
+	}
+
+	if req.Screen {
+		actions = append(actions, browser.FullScreenshot(100, &p.Screen))
+	}
+
+	if req.Next != "" && req.Selector != "" {
+		actions = append(actions, pager(req, &p))
+	}
+
+	return p, c.browser.Run(req.URL, actions...)
+}
+
+// pager clicks req.Next until it is gone or disabled, reading req.Selector
+// once req.Changes renders differently after every click.
+func pager(req Request, p *Page) chromedp.Action {
+	changes := req.Changes
+	if changes == "" {
+		changes = req.Selector
+	}
+
+	click := `(function() {
+	var a = document.querySelector(` + strconv.Quote(req.Next) + `);
+	if (!a || a.classList.contains("disabled") || a.getAttribute("aria-disabled") === "true") {
+		return false;
+	}
+	a.click();
+	return true;
+})()`
+
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		p.Views = []string{p.HTML}
+
+		for len(p.Views) < maxViews {
+			before := ""
+			if err := chromedp.OuterHTML(changes, &before, chromedp.ByQuery).Do(ctx); err != nil {
+				return err
+			}
+
+			clicked := false
+			if err := chromedp.Evaluate(click, &clicked).Do(ctx); err != nil {
+				return errors.WithMessagef(err, "pager: click fail -> %s", req.Next)
+			}
+
+			if !clicked {
+				return nil
+			}
+
+			for after, deadline := before, time.Now().Add(changeTimeout); after == before; {
+				if time.Now().After(deadline) {
+					return errors.Errorf("pager: %s unchanged after clicking %s", changes, req.Next)
+				}
+
+				time.Sleep(200 * time.Millisecond)
+				if err := chromedp.OuterHTML(changes, &after, chromedp.ByQuery).Do(ctx); err != nil {
+					return err
+				}
+			}
+
+			view := ""
+			if err := chromedp.InnerHTML(req.Selector, &view, chromedp.NodeVisible, chromedp.ByQuery).Do(ctx); err != nil {
+				return err
+			}
+
+			p.Views = append(p.Views, view)
+		}
+
+		return nil
+	})
+}
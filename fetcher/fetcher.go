@@ -0,0 +1,120 @@
+// Package fetcher renders lantouzi.com pages for the export and download
+// packages, either live through Chrome or from pages saved on disk.
+package fetcher
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound is returned when a page is not available, e.g. not in a capture.
+var ErrNotFound = errors.New("page not found")
+
+// Request describes a page to render, selectors are CSS selectors.
+type Request struct {
+	URL string
+	// Selector picks the element whose inner html becomes Page.HTML
+	Selector string
+	// Wait must match before the page is read
+	Wait string
+	// Screen asks for a full page png
+	Screen bool
+	// Document asks for the html of the whole page
+	Document bool
+	// Next is a pager's next link, followed until it is gone or disabled,
+	// reading Selector again on every view into Page.Views
+	Next string
+	// Changes must render differently after Next is clicked before the view
+	// is read, Selector when empty
+	Changes string
+}
+
+// maxViews bounds how many pager views one Request follows.
+const maxViews = 500
+
+// Page is what one render of a Request collected.
+type Page struct {
+	HTML     string
+	Screen   []byte
+	Document string
+	// Views is Selector's html on every pager view when Request.Next is set,
+	// HTML being the first
+	Views []string
+}
+
+type Fetcher interface {
+	Fetch(req Request) (Page, error)
+}
+
+// Key names the files a page is saved under.
+func Key(url string) string {
+	sum := sha1.Sum([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+// nextLink is the url a plain next link leads to, empty for the last view and
+// for links driven by script, which only a browser can follow.
+func nextLink(doc, next, base string) (string, error) {
+	dom, err := goquery.NewDocumentFromReader(strings.NewReader(doc))
+	if err != nil {
+		return "", errors.WithMessagef(err, "nextLink: load html to dom fail -> %s", base)
+	}
+
+	link := dom.Find(next).First()
+	if link.Length() == 0 || link.HasClass("disabled") {
+		return "", nil
+	}
+
+	href := strings.TrimSpace(link.AttrOr("href", ""))
+	if href == "" || href == "#" || strings.HasPrefix(href, "javascript") {
+		return "", nil
+	}
+
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", errors.WithMessagef(err, "nextLink: invalid url -> %s", base)
+	}
+
+	r, err := url.Parse(href)
+	if err != nil {
+		return "", errors.WithMessagef(err, "nextLink: invalid link -> %s", href)
+	}
+
+	return b.ResolveReference(r).String(), nil
+}
+
+// fromDocument answers a Request from an already rendered document.
+func fromDocument(doc string, req Request) (Page, error) {
+	p := Page{}
+
+	dom, err := goquery.NewDocumentFromReader(strings.NewReader(doc))
+	if err != nil {
+		return p, errors.WithMessagef(err, "fromDocument: load html to dom fail -> %s", req.URL)
+	}
+
+	if req.Wait != "" && dom.Find(req.Wait).Length() == 0 {
+		return p, errors.Errorf("fromDocument: wait selector not found %s -> %s", req.Wait, req.URL)
+	}
+
+	if req.Selector != "" {
+		sel := dom.Find(req.Selector).First()
+		if sel.Length() == 0 {
+			return p, errors.Errorf("fromDocument: selector not found %s -> %s", req.Selector, req.URL)
+		}
+
+		if p.HTML, err = sel.Html(); err != nil {
+			return p, errors.WithMessagef(err, "fromDocument: render selection fail -> %s", req.URL)
+		}
+	}
+
+	if req.Document {
+		p.Document = doc
+	}
+
+	return p, nil
+}
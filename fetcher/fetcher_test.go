@@ -0,0 +1,134 @@
+package fetcher_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/HarryBird/lantouzi-export/fetcher"
+	"github.com/HarryBird/lantouzi-export/ltztest"
+	"github.com/pkg/errors"
+)
+
+const table = "body > div.g-uc-page.clearfix > div.g-uc-main > div > div.bd > div:nth-child(2) > table"
+
+func TestRecordReplay(t *testing.T) {
+	srv := ltztest.NewServer(ltztest.Trades(3), nil)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	url := srv.URL + "/user/trade/datalist?page=1&size=10"
+
+	live, err := fetcher.NewHTTP(nil, srv.Cookies())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := fetcher.NewRecorder(live, dir).Fetch(fetcher.Request{URL: url, Selector: table})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want.Document != "" {
+		t.Error("recorder returned a document nobody asked for")
+	}
+
+	got, err := fetcher.NewReplay(dir).Fetch(fetcher.Request{URL: url, Selector: table})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.HTML != want.HTML || got.HTML == "" {
+		t.Errorf("replayed html %q, recorded %q", got.HTML, want.HTML)
+	}
+
+	if _, err := fetcher.NewReplay(dir).Fetch(fetcher.Request{URL: url + "&type=1"}); errors.Cause(err) != fetcher.ErrNotFound {
+		t.Errorf("got %v for a page not in the capture, want ErrNotFound", err)
+	}
+}
+
+func TestTransportRecordReplay(t *testing.T) {
+	srv := ltztest.NewServer(nil, []ltztest.Service{{
+		ID:        "s1",
+		Agreement: &ltztest.Contract{ID: "a1", File: "agreement.pdf", Body: []byte("agreement")},
+	}})
+	defer srv.Close()
+
+	dir := t.TempDir()
+	url := srv.URL + "/user/contract/download?id=a1"
+
+	get := func(rt http.RoundTripper) (*http.Response, string) {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.AddCookie(&http.Cookie{Name: ltztest.CookieName, Value: ltztest.CookieValue})
+
+		r, err := (&http.Client{Transport: rt}).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return r, string(body)
+	}
+
+	_, recorded := get(fetcher.NewRecordTransport(nil, dir))
+	r, replayed := get(fetcher.NewReplayTransport(dir))
+
+	if replayed != recorded || replayed != "agreement" {
+		t.Errorf("replayed body %q, recorded %q", replayed, recorded)
+	}
+
+	if r.Header.Get("Content-Disposition") == "" {
+		t.Error("replayed response lost its headers")
+	}
+}
+
+func TestPagerRecordReplay(t *testing.T) {
+	sv := ltztest.Service{ID: "s1", Name: "智选服务6月期D1", Status: 3}
+	for _, name := range []string{"项目1", "项目2", "项目3", "项目4", "项目5"} {
+		sv.Projects = append(sv.Projects, ltztest.Project{Name: name})
+	}
+
+	srv := ltztest.NewServer(nil, []ltztest.Service{sv})
+	srv.ProjectPageSize = 2
+	srv.LinkPager = true
+	defer srv.Close()
+
+	dir := t.TempDir()
+	req := fetcher.Request{
+		URL:      srv.URL + "/user/smartbid/order/detail?id=s1&smb_type=1",
+		Selector: "#buy_prj_relation_list",
+		Next:     "#buy_prj_relation_pager a.next",
+	}
+
+	live, err := fetcher.NewHTTP(nil, srv.Cookies())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := fetcher.NewRecorder(live, dir).Fetch(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(want.Views) != 3 || !strings.Contains(want.Views[2], "项目5") {
+		t.Fatalf("followed %d pager views %v, want 3 ending with 项目5", len(want.Views), want.Views)
+	}
+
+	got, err := fetcher.NewReplay(dir).Fetch(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(got.Views, "|") != strings.Join(want.Views, "|") {
+		t.Errorf("replayed views %v, recorded %v", got.Views, want.Views)
+	}
+}
@@ -0,0 +1,102 @@
+package fetcher
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// HTTP fetches pages with plain GET requests. It runs no javascript and takes
+// no screenshots, which is enough for server rendered pages and test servers.
+type HTTP struct {
+	client  *http.Client
+	cookies []*http.Cookie
+}
+
+func NewHTTP(client *http.Client, cookies []map[string]interface{}) (*HTTP, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	h := &HTTP{client: client}
+
+	for _, c := range cookies {
+		var ck http.Cookie
+		if err := mapstructure.Decode(c, &ck); err != nil {
+			return nil, errors.WithMessagef(err, "NewHTTP: build cookie fail %v", c)
+		}
+
+		h.cookies = append(h.cookies, &ck)
+	}
+
+	return h, nil
+}
+
+// Fetch follows a Request.Next pager only through plain links, a pager driven
+// by script leaves Page.Views at the first view.
+func (h *HTTP) Fetch(req Request) (Page, error) {
+	doc, err := h.get(req.URL)
+	if err != nil {
+		return Page{}, err
+	}
+
+	p, err := fromDocument(doc, req)
+	if err != nil || req.Next == "" {
+		return p, err
+	}
+
+	p.Views = []string{p.HTML}
+	seen := map[string]bool{req.URL: true}
+
+	for len(p.Views) < maxViews {
+		next, err := nextLink(doc, req.Next, req.URL)
+		if err != nil || next == "" || seen[next] {
+			return p, err
+		}
+		seen[next] = true
+
+		if doc, err = h.get(next); err != nil {
+			return p, err
+		}
+
+		view, err := fromDocument(doc, Request{URL: next, Selector: req.Selector})
+		if err != nil {
+			return p, err
+		}
+
+		p.Views = append(p.Views, view.HTML)
+	}
+
+	return p, nil
+}
+
+func (h *HTTP) get(url string) (string, error) {
+	r, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for _, ck := range h.cookies {
+		r.AddCookie(ck)
+	}
+
+	resp, err := h.client.Do(r)
+	if err != nil {
+		return "", errors.WithMessagef(err, "HTTP: request fail -> %s", url)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("HTTP: unexpected status %d -> %s", resp.StatusCode, url)
+	}
+
+	doc, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.WithMessagef(err, "HTTP: read body fail -> %s", url)
+	}
+
+	return string(doc), nil
+}
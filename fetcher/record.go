@@ -0,0 +1,88 @@
+package fetcher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const indexFile = "index.txt"
+
+// Recorder saves every page it fetches in the layout Replay reads back.
+type Recorder struct {
+	fetcher Fetcher
+	dir     string
+	mu      sync.Mutex
+}
+
+func NewRecorder(f Fetcher, dir string) *Recorder {
+	return &Recorder{fetcher: f, dir: dir}
+}
+
+func (r *Recorder) Fetch(req Request) (Page, error) {
+	want := req.Document
+
+	// the whole document is kept so replay can answer any selector
+	req.Document = true
+	p, err := r.fetcher.Fetch(req)
+	if err != nil {
+		return p, err
+	}
+
+	if err := r.save(req.URL, ".html", []byte(p.Document)); err != nil {
+		return p, err
+	}
+
+	if req.Screen {
+		if err := r.save(req.URL, ".png", p.Screen); err != nil {
+			return p, err
+		}
+	}
+
+	// pager views past the first are only Selector's html, see viewUrl
+	for i := 1; i < len(p.Views); i++ {
+		if err := r.save(viewUrl(req.URL, i), ".html", []byte(p.Views[i])); err != nil {
+			return p, err
+		}
+	}
+
+	if !want {
+		p.Document = ""
+	}
+
+	return p, nil
+}
+
+func (r *Recorder) save(url, ext string, buf []byte) error {
+	return save(r.dir, url, ext, buf, &r.mu)
+}
+
+// save writes one captured file and notes its url in the index, which is
+// only there for humans looking for a page in the capture.
+func save(dir, url, ext string, buf []byte, mu *sync.Mutex) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.WithMessagef(err, "save: create dir fail -> %s", dir)
+	}
+
+	key := Key(url)
+	if err := ioutil.WriteFile(filepath.Join(dir, key+ext), buf, 0755); err != nil {
+		return errors.WithMessagef(err, "save: write file fail -> %s", url)
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, indexFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0755)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	_, err = f.WriteString(key + ext + " " + url + "\n")
+
+	return err
+}
@@ -0,0 +1,68 @@
+package fetcher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Replay serves pages saved under dir as <Key(url)>.html, with an optional
+// <Key(url)>.png screenshot, so archived pages can be parsed without Chrome.
+type Replay struct {
+	dir string
+}
+
+func NewReplay(dir string) *Replay {
+	return &Replay{dir: dir}
+}
+
+func (r *Replay) Fetch(req Request) (Page, error) {
+	file := filepath.Join(r.dir, Key(req.URL))
+
+	doc, err := ioutil.ReadFile(file + ".html")
+	if os.IsNotExist(err) {
+		return Page{}, errors.WithMessagef(ErrNotFound, "Replay: %s", req.URL)
+	}
+
+	if err != nil {
+		return Page{}, err
+	}
+
+	p, err := fromDocument(string(doc), req)
+	if err != nil {
+		return p, err
+	}
+
+	if req.Screen {
+		if p.Screen, err = ioutil.ReadFile(file + ".png"); err != nil && !os.IsNotExist(err) {
+			return p, err
+		}
+	}
+
+	if req.Next != "" {
+		p.Views = []string{p.HTML}
+
+		for i := 1; i < maxViews; i++ {
+			view, err := ioutil.ReadFile(filepath.Join(r.dir, Key(viewUrl(req.URL, i))+".html"))
+			if os.IsNotExist(err) {
+				break
+			}
+
+			if err != nil {
+				return p, err
+			}
+
+			p.Views = append(p.Views, string(view))
+		}
+	}
+
+	return p, nil
+}
+
+// viewUrl names the i-th pager view of a page in a capture.
+func viewUrl(url string, i int) string {
+	return url + "#view-" + strconv.Itoa(i)
+}
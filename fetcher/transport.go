@@ -0,0 +1,110 @@
+package fetcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// response is the part of an http response a capture keeps next to the body.
+type response struct {
+	Status int
+	Header http.Header
+}
+
+// RecordTransport saves every response body as <Key(url)>.body and its status
+// and headers as <Key(url)>.json.
+type RecordTransport struct {
+	base http.RoundTripper
+	dir  string
+	mu   sync.Mutex
+}
+
+func NewRecordTransport(base http.RoundTripper, dir string) *RecordTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &RecordTransport{base: base, dir: dir}
+}
+
+func (t *RecordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r, err := t.base.RoundTrip(req)
+	if err != nil {
+		return r, err
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "RecordTransport: read body fail -> %s", req.URL)
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	meta, err := json.Marshal(response{Status: r.StatusCode, Header: r.Header})
+	if err != nil {
+		return nil, err
+	}
+
+	url := req.URL.String()
+	if err := save(t.dir, url, ".body", body, &t.mu); err != nil {
+		return nil, err
+	}
+
+	if err := save(t.dir, url, ".json", meta, &t.mu); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// ReplayTransport answers requests from a capture written by RecordTransport.
+type ReplayTransport struct {
+	dir string
+}
+
+func NewReplayTransport(dir string) *ReplayTransport {
+	return &ReplayTransport{dir: dir}
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	file := filepath.Join(t.dir, Key(req.URL.String()))
+
+	buf, err := ioutil.ReadFile(file + ".json")
+	if os.IsNotExist(err) {
+		return nil, errors.WithMessagef(ErrNotFound, "ReplayTransport: %s", req.URL)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	meta := response{}
+	if err := json.Unmarshal(buf, &meta); err != nil {
+		return nil, errors.WithMessagef(err, "ReplayTransport: decode fail -> %s", req.URL)
+	}
+
+	body, err := ioutil.ReadFile(file + ".body")
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(meta.Status),
+		StatusCode:    meta.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        meta.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
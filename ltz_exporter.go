@@ -2,12 +2,17 @@ package main
 
 import (
 	"log"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/HarryBird/cdp"
+	"github.com/HarryBird/lantouzi-export/browser"
 	"github.com/HarryBird/lantouzi-export/download"
 	"github.com/HarryBird/lantouzi-export/export"
+	"github.com/HarryBird/lantouzi-export/fetcher"
+	"github.com/HarryBird/lantouzi-export/site"
+	"github.com/HarryBird/lantouzi-export/store"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -17,16 +22,29 @@ var (
 )
 
 type target struct {
+	// Type is the trade list type, 0 all, 1 充值 2 提现 3 投资 4 利息 5 回收本金 6 平台奖励 7 其他 8 手续费
+	Type int
+	// Url overrides Type with a page of its own, relative to the site or absolute
 	Url    string
 	Name   string
 	Screen bool
 	Parse  bool
+	// Archive keeps the raw html of every page
+	Archive bool
+	// Formats of the record files, csv by default
+	Formats []string
+	// Column optionally pins the expected column count, detected from the page otherwise
 	Column int
 }
 
 type config struct {
+	Site    site.Site
 	Cookies []map[string]interface{}
 	Targets []target
+	// Accounts of the beancount and ledger formats
+	Accounts export.Accounts
+	// Statuses are the order statuses to collect, see orderStatuses
+	Statuses []download.Status
 }
 
 func initConfig() config {
@@ -47,6 +65,8 @@ func initConfig() config {
 		logger.Panicf("%s %s %+v", "[PANIC] ", "Parse Config File Fail ->", err)
 	}
 
+	conf.Site = conf.Site.WithDefaults()
+
 	if len(conf.Cookies) > 0 {
 		for _, cookie := range conf.Cookies {
 			if _, ok := cookie["ExpireWithIn"]; ok {
@@ -60,24 +80,161 @@ func initConfig() config {
 	return conf
 }
 
+// newFetcher picks how pages and files are fetched from the --record and
+// --replay flags, the returned func releases the browser if one was started.
+func newFetcher(cmd *cobra.Command, config config) (fetcher.Fetcher, http.RoundTripper, func()) {
+	record, _ := cmd.Flags().GetString("record")
+	replay, _ := cmd.Flags().GetString("replay")
+
+	if replay != "" {
+		logger.Printf("%s %s %s", "[INFO] ", "Replay Capture ->", replay)
+		return fetcher.NewReplay(replay), fetcher.NewReplayTransport(replay), func() {}
+	}
+
+	b, err := browser.New(browser.WithCookies(config.Cookies))
+	if err != nil {
+		logger.Panicf("%s %s %+v", "[PANIC] ", "Start Browser Fail", err)
+	}
+
+	if record != "" {
+		logger.Printf("%s %s %s", "[INFO] ", "Record Capture ->", record)
+		return fetcher.NewRecorder(fetcher.NewChrome(b), record), fetcher.NewRecordTransport(nil, record), b.Close
+	}
+
+	return fetcher.NewChrome(b), nil, b.Close
+}
+
+// openStore opens the --db database, nil when the flag is not set.
+func openStore(cmd *cobra.Command) *store.Store {
+	file, _ := cmd.Flags().GetString("db")
+	if file == "" {
+		return nil
+	}
+
+	db, err := store.Open(file)
+	if err != nil {
+		logger.Panicf("%s %s %+v", "[PANIC] ", "Open Database Fail", err)
+	}
+
+	logger.Printf("%s %s %s", "[INFO] ", "Write Database ->", file)
+
+	return db
+}
+
 func runDownload(cmd *cobra.Command, args []string) {
+	downloadWith(cmd, false)
+}
+
+// runServices collects the services without downloading any contract.
+func runServices(cmd *cobra.Command, args []string) {
+	downloadWith(cmd, true)
+}
+
+// orderStatuses are the statuses to collect, the --status codes named from
+// DefaultStatuses and config, config statuses when the flag is not set, and
+// 已退出 when neither is.
+func orderStatuses(cmd *cobra.Command, config config) []download.Status {
+	codes, _ := cmd.Flags().GetIntSlice("status")
+
+	if !cmd.Flags().Changed("status") {
+		if len(config.Statuses) > 0 {
+			return config.Statuses
+		}
+
+		return []download.Status{{Code: 3, Name: "已退出"}}
+	}
+
+	names := map[int]string{}
+	for _, s := range append(download.DefaultStatuses(), config.Statuses...) {
+		names[s.Code] = s.Name
+	}
+
+	statuses := []download.Status{}
+	for _, code := range codes {
+		statuses = append(statuses, download.Status{Code: code, Name: names[code]})
+	}
+
+	return statuses
+}
+
+func downloadWith(cmd *cobra.Command, metadata bool) {
 	config := initConfig()
 
 	if len(config.Cookies) == 0 {
 		logger.Panicf("%s %s", "[PANIC] ", "Empty Cookie Setting")
 	}
 
+	f, rt, closer := newFetcher(cmd, config)
+	defer closer()
+
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	rps, _ := cmd.Flags().GetFloat64("rps")
+	burst, _ := cmd.Flags().GetInt("burst")
+
 	downloader := download.New(
 		download.WithCookies(config.Cookies),
+		download.WithSelectors(loadSelectors(cmd)),
+		download.WithSite(config.Site),
+		download.WithFetcher(f),
+		download.WithTransport(rt),
+		download.WithMetadata(metadata),
+		download.WithStatuses(orderStatuses(cmd, config)),
+		download.WithConcurrency(concurrency),
+		download.WithRateLimit(rps, burst),
 	)
 
+	db := openStore(cmd)
+	if db != nil {
+		defer db.Close()
+	}
+
 	if err := downloader.Run(); err != nil {
 		logger.Panicf("%s %s %+v", "[ERROR] ", "Downloader Run Fail", err)
 	}
 
+	if db != nil {
+		if err := db.SaveServices(downloader.Services(), downloader.Projects()); err != nil {
+			logger.Panicf("%s %s %+v", "[ERROR] ", "Save Services Fail", err)
+		}
+
+		if err := db.SaveContracts(downloader.Contracts()); err != nil {
+			logger.Panicf("%s %s %+v", "[ERROR] ", "Save Contracts Fail", err)
+		}
+	}
+}
+
+const (
+	// xlsxFormat collects targets into one workbook rather than a file per target
+	xlsxFormat   = "xlsx"
+	workbookFile = "./lantouzi/lantouzi.xlsx"
+)
+
+// splitFormats separates the per target record formats from the workbook.
+func splitFormats(formats []string) ([]string, bool) {
+	record := []string{}
+	workbook := false
+
+	for _, format := range formats {
+		if format == xlsxFormat {
+			workbook = true
+			continue
+		}
+		record = append(record, format)
+	}
+
+	return record, workbook
 }
 
 func runExport(cmd *cobra.Command, args []string) {
+	// os.Exit skips deferred calls, the browser and db are closed by exportTargets first
+	if broken := exportTargets(cmd); broken > 0 {
+		os.Exit(1)
+	}
+}
+
+// exportTargets exports every target and returns the number of targets whose
+// balance chain is broken when --verify is set.
+func exportTargets(cmd *cobra.Command) int {
 	config := initConfig()
 
 	if len(config.Cookies) == 0 {
@@ -88,32 +245,107 @@ func runExport(cmd *cobra.Command, args []string) {
 		logger.Panicf("%s %s", "[PANIC] ", "Empty Target Setting")
 	}
 
+	f, _, closer := newFetcher(cmd, config)
+	defer closer()
+
+	profile := loadSelectors(cmd)
+	formats, _ := cmd.Flags().GetStringSlice("format")
+
 	for _, target := range config.Targets {
-		if target.Url == "" || target.Name == "" {
+		for _, format := range append(formats, target.Formats...) {
+			if format == xlsxFormat {
+				continue
+			}
+
+			if _, err := export.NewWriter(format); err != nil {
+				logger.Panicf("%s %s %+v", "[PANIC] ", "Invalid Format ->", err)
+			}
+		}
+	}
+
+	resume, _ := cmd.Flags().GetBool("resume")
+	incremental, _ := cmd.Flags().GetBool("incremental")
+	verify, _ := cmd.Flags().GetBool("verify")
+	source, _ := cmd.Flags().GetBool("source-columns")
+
+	sheets := []export.Sheet{}
+	broken := 0
+
+	db := openStore(cmd)
+	if db != nil {
+		defer db.Close()
+	}
+
+	for _, target := range config.Targets {
+		if target.Name == "" {
 			logger.Printf("%s %s", "[WARN] ", "Invalid Target, Ignore...")
 			continue
 		}
 
-		if target.Column == 0 {
-			logger.Printf("%s %s", "[WARN] ", "Invalid Target, Ignore...")
+		url, err := config.Site.TradeUrl(target.Type)
+		if target.Url != "" {
+			url, err = config.Site.Resolve(target.Url)
+		}
+
+		if err != nil {
+			logger.Printf("%s %s %+v", "[WARN] ", "Invalid Target Url, Ignore...", err)
 			continue
 		}
 
+		targetFormats, workbook := splitFormats(target.Formats)
+		if len(formats) > 0 {
+			targetFormats, workbook = splitFormats(formats)
+		}
+
 		exporter := export.New(
 			export.WithCookies(config.Cookies),
-			export.WithUrl(target.Url),
+			export.WithUrl(url),
 			export.WithName(target.Name),
 			export.WithScreen(target.Screen),
 			export.WithParse(target.Parse),
+			export.WithArchive(target.Archive),
 			export.WithColumn(target.Column),
+			export.WithResume(resume),
+			export.WithIncremental(incremental),
+			export.WithFetcher(f),
+			export.WithSelectors(profile),
+			export.WithFormats(targetFormats),
+			export.WithAccounts(config.Accounts),
+			// breaks are counted below, so one target's breaks don't stop the others
+			export.WithVerify(false),
+			export.WithSourceColumns(source),
 		)
 
 		if err := exporter.Run(); err != nil {
 			logger.Panicf("%s %s %+v", "[ERROR] ", "Exporter Run Fail", err)
 		}
 
+		if breaks := exporter.Breaks(); verify && len(breaks) > 0 {
+			logger.Printf("%s %s %s %d", "[ERROR] ", "Balance Chain Broken ->", target.Name, len(breaks))
+			broken += 1
+		}
+
+		if workbook {
+			sheets = append(sheets, exporter.Sheet())
+		}
+
+		if db != nil {
+			if err := db.SaveTransactions(exporter.Transactions()); err != nil {
+				logger.Panicf("%s %s %+v", "[ERROR] ", "Save Transactions Fail", err)
+			}
+		}
+
 		time.Sleep(1 * time.Second)
 	}
+
+	if len(sheets) > 0 {
+		logger.Printf("%s %s %s", "[INFO] ", "Write Workbook ->", workbookFile)
+		if err := export.WriteWorkbook(workbookFile, sheets); err != nil {
+			logger.Panicf("%s %s %+v", "[ERROR] ", "Write Workbook Fail", err)
+		}
+	}
+
+	return broken
 }
 
 func main() {
@@ -127,6 +359,24 @@ func main() {
 		Short: "Export Lantouzi.com Account's Records",
 		Run:   runExport,
 	}
+	export.Flags().Bool("resume", false, "continue every target from its last checkpoint")
+	export.Flags().Bool("incremental", false, "only fetch records newer than the existing record files")
+	export.Flags().StringSlice("format", nil, "record file formats, csv json jsonl beancount ledger ofx qif xlsx, overrides the target formats")
+	export.Flags().Bool("verify", true, "exit non-zero after every target is exported when a balance chain is broken, --verify=false only logs it")
+	export.Flags().Bool("source-columns", false, "add the 页码 column to record.csv")
+
+	verify := &cobra.Command{
+		Use:   "verify [target...]",
+		Short: "Verify The Balance Chain Of Exported Records",
+		Run:   runVerify,
+	}
+
+	reconcile := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Reconcile The 全部 Records Against Every Category",
+		Run:   runReconcile,
+	}
+	reconcile.Flags().String("all", "全部", "name of the unfiltered target")
 
 	download := &cobra.Command{
 		Use:   "download",
@@ -134,7 +384,44 @@ func main() {
 		Run:   runDownload,
 	}
 
-	root.AddCommand(export, download)
+	services := &cobra.Command{
+		Use:   "services",
+		Short: "Export Lantouzi.com Account's Services Without Agreements",
+		Run:   runServices,
+	}
+
+	selectors := &cobra.Command{
+		Use:   "selectors",
+		Short: "Manage The Selector Profile",
+	}
+
+	check := &cobra.Command{
+		Use:   "check",
+		Short: "Check Every Selector Still Matches Lantouzi.com",
+		Run:   runSelectorsCheck,
+	}
+	check.Flags().String("detail", "", "service detail url to check, the first listed service by default")
+	selectors.AddCommand(check)
+
+	for _, cmd := range []*cobra.Command{export, download, services} {
+		cmd.Flags().String("db", "", "also write results into this sqlite database, e.g. ./lantouzi/lantouzi.db")
+	}
+
+	for _, cmd := range []*cobra.Command{download, services} {
+		cmd.Flags().IntSlice("status", nil, "order statuses to collect, 1 加入中 2 持有中 3 已退出 4 退出中, the config statuses by default")
+	}
+
+	download.Flags().Int("concurrency", 4, "contracts downloaded at once")
+	download.Flags().Float64("rps", 2, "contract requests per second, shared by every download")
+	download.Flags().Int("burst", 1, "contract requests allowed at once above --rps")
+
+	for _, cmd := range []*cobra.Command{export, download, services, check} {
+		cmd.Flags().String("record", "", "save every fetched page and file into this dir")
+		cmd.Flags().String("replay", "", "run against a dir saved by --record, without network")
+		cmd.Flags().String("selectors", "./selectors.yaml", "selector profile file")
+	}
+
+	root.AddCommand(export, download, services, selectors, verify, reconcile)
 	root.Execute()
 }
 
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+
+	"github.com/HarryBird/lantouzi-export/export"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const reconcileFile = "./lantouzi/流水/reconcile.txt"
+
+// runReconcile checks the exported 全部 target against the union of every
+// other configured target, and writes the report next to the record files.
+func runReconcile(cmd *cobra.Command, args []string) {
+	config := initConfig()
+	allName, _ := cmd.Flags().GetString("all")
+
+	_, all, err := export.Load(allName)
+	if err != nil {
+		logger.Panicf("%s %s %+v", "[PANIC] ", "Load Record File Fail ->", err)
+	}
+
+	categories := map[string][]export.Transaction{}
+	for _, target := range config.Targets {
+		if target.Name == "" || target.Name == allName {
+			continue
+		}
+
+		_, records, err := export.Load(target.Name)
+		if os.IsNotExist(errors.Cause(err)) {
+			logger.Printf("%s %s %s", "[WARN] ", "No Record File, Ignore ->", target.Name)
+			continue
+		}
+
+		if err != nil {
+			logger.Panicf("%s %s %+v", "[PANIC] ", "Load Record File Fail ->", err)
+		}
+
+		categories[target.Name] = records
+	}
+
+	r := export.Reconcile(allName, all, categories)
+
+	buf := &bytes.Buffer{}
+	if err := r.WriteReport(buf); err != nil {
+		logger.Panicf("%s %s %+v", "[PANIC] ", "Write Report Fail", err)
+	}
+
+	os.Stdout.Write(buf.Bytes())
+
+	if err := ioutil.WriteFile(reconcileFile, buf.Bytes(), 0755); err != nil {
+		logger.Panicf("%s %s %+v", "[PANIC] ", "Write Report Fail", err)
+	}
+
+	logger.Printf("%s %s %s", "[INFO] ", "Write Report ->", reconcileFile)
+
+	if !r.OK() {
+		os.Exit(1)
+	}
+}
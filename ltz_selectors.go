@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/HarryBird/lantouzi-export/download"
+	"github.com/HarryBird/lantouzi-export/fetcher"
+	"github.com/HarryBird/lantouzi-export/selector"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/spf13/cobra"
+)
+
+func loadSelectors(cmd *cobra.Command) selector.Profile {
+	file, _ := cmd.Flags().GetString("selectors")
+
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		logger.Printf("%s %s %s", "[INFO] ", "No Selector Profile, Use Default ->", file)
+		return selector.Default()
+	}
+
+	profile, err := selector.Load(file)
+	if err != nil {
+		logger.Panicf("%s %s %+v", "[PANIC] ", "Load Selector Profile Fail ->", err)
+	}
+
+	return profile
+}
+
+// firstOrder finds a service detail url on the order list to check the detail selectors against.
+func firstOrder(profile selector.Profile, doc string) string {
+	dom, err := goquery.NewDocumentFromReader(strings.NewReader(doc))
+	if err != nil {
+		return ""
+	}
+
+	href, _ := dom.Find(profile.Css(selector.OrderItem)).Find(profile.Css(selector.OrderLink)).First().Attr("href")
+
+	return strings.TrimSpace(href)
+}
+
+// orderListPage is the order list of the first collected status showing an
+// order, the order selectors need one to match, else the first status's list.
+func orderListPage(f fetcher.Fetcher, profile selector.Profile, config config, statuses []download.Status) string {
+	first := ""
+
+	for _, status := range statuses {
+		url, err := config.Site.OrderListUrl(status.Code, 1)
+		if err != nil {
+			logger.Panicf("%s %s %+v", "[PANIC] ", "Build Url Fail", err)
+		}
+
+		if first == "" {
+			first = url
+		}
+
+		p, err := f.Fetch(fetcher.Request{URL: url, Document: true})
+		if err == nil && firstOrder(profile, p.Document) != "" {
+			return url
+		}
+	}
+
+	return first
+}
+
+func runSelectorsCheck(cmd *cobra.Command, args []string) {
+	// os.Exit skips deferred calls, the browser is closed by checkSelectors first
+	if failed := checkSelectors(cmd); failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// checkSelectors renders every page the profile covers and returns the number of failures.
+func checkSelectors(cmd *cobra.Command) int {
+	config := initConfig()
+	profile := loadSelectors(cmd)
+
+	f, _, closer := newFetcher(cmd, config)
+	defer closer()
+
+	trade, err := config.Site.TradeUrl(0)
+	if err != nil {
+		logger.Panicf("%s %s %+v", "[PANIC] ", "Build Url Fail", err)
+	}
+
+	orders := orderListPage(f, profile, config, orderStatuses(cmd, config))
+
+	detail, _ := cmd.Flags().GetString("detail")
+	failed := 0
+
+	for _, page := range []string{selector.TradeList, selector.OrderList, selector.OrderDetail} {
+		req := fetcher.Request{Document: true}
+
+		switch page {
+		case selector.TradeList:
+			req.URL = trade
+		case selector.OrderList:
+			req.URL = orders
+		case selector.OrderDetail:
+			req.URL, req.Wait = detail, profile.Css(selector.ProjectReady)
+		}
+
+		if req.URL == "" {
+			logger.Printf("%s %s %s", "[WARN] ", "No Page To Check, Skip ->", page)
+			continue
+		}
+
+		logger.Printf("%s %s %s %s", "[INFO] ", "Check Page ->", page, req.URL)
+
+		p, err := f.Fetch(req)
+		if err != nil && req.Wait != "" {
+			logger.Printf("%s %s %v", "[WARN] ", "Wait Fail, Read Page As Is ->", err)
+			req.Wait = ""
+			p, err = f.Fetch(req)
+		}
+
+		if err != nil {
+			logger.Printf("%s %s %s %v", "[ERROR] ", "Render Page Fail ->", page, err)
+			failed += 1
+			continue
+		}
+
+		results, err := profile.Check(page, p.Document)
+		if err != nil {
+			logger.Printf("%s %s %s %v", "[ERROR] ", "Check Page Fail ->", page, err)
+			failed += 1
+			continue
+		}
+
+		for _, r := range results {
+			if r.Matches == 0 && r.Optional {
+				logger.Printf("%s %s %s %q", "[WARN] ", "NO MATCH, OPTIONAL", r.Name, r.Css)
+				continue
+			}
+
+			if r.Matches == 0 {
+				failed += 1
+				logger.Printf("%s %s %s %q", "[ERROR] ", "NO MATCH", r.Name, r.Css)
+				continue
+			}
+
+			logger.Printf("%s %s %s %q %d", "[INFO] ", "OK", r.Name, r.Css, r.Matches)
+		}
+
+		if page == selector.OrderList && detail == "" {
+			if href := firstOrder(profile, p.Document); href != "" {
+				detail, _ = config.Site.Resolve(href)
+			}
+		}
+	}
+
+	logger.Printf("%s %s %d %s %d", "[INFO] ", "Profile Version ->", profile.Version, "Failures ->", failed)
+
+	return failed
+}
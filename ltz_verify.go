@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+
+	"github.com/HarryBird/lantouzi-export/export"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// runVerify checks the balance chain of the exported record files, every
+// target with 账户余额 by default or the targets named as args.
+func runVerify(cmd *cobra.Command, args []string) {
+	names := args
+	if len(names) == 0 {
+		for _, target := range initConfig().Targets {
+			names = append(names, target.Name)
+		}
+	}
+
+	broken := 0
+
+	for _, name := range names {
+		schema, records, err := export.Load(name)
+		if os.IsNotExist(errors.Cause(err)) {
+			logger.Printf("%s %s %s", "[ERROR] ", "No Record File, export the target with the csv format ->", name)
+			broken += 1
+			continue
+		}
+
+		if err != nil {
+			logger.Panicf("%s %s %+v", "[PANIC] ", "Load Record File Fail ->", err)
+		}
+
+		if !schema.Balance() {
+			logger.Printf("%s %s %s", "[INFO] ", "No Balance Column, Ignore ->", name)
+			continue
+		}
+
+		breaks := export.VerifyBalance(records)
+		for _, b := range breaks {
+			logger.Printf("%s %s %s", "[ERROR] ", name, b.Error())
+		}
+
+		logger.Printf("%s %s %s %d %s %d", "[INFO] ", name, "records ->", len(records), "breaks ->", len(breaks))
+		broken += len(breaks)
+	}
+
+	if broken > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,351 @@
+// Package ltztest runs a local stand-in for the lantouzi.com pages the
+// exporter and downloader read, for end-to-end tests without a live login.
+package ltztest
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"github.com/HarryBird/lantouzi-export/site"
+)
+
+const (
+	CookieName  = "LTZ_S"
+	CookieValue = "ltztest-session"
+)
+
+// Trade is one row of /user/trade/datalist.
+type Trade struct {
+	// Type is the lantouzi.com trade type, 1 充值 2 提现 3 投资 4 利息 5 回收本金 6 平台奖励 7 其他 8 手续费
+	Type    int
+	Amount  string
+	Desc    string
+	Balance string
+	Time    string
+}
+
+// Contract is a file served from /user/contract/download.
+type Contract struct {
+	ID   string
+	File string
+	Body []byte
+}
+
+// Project is a loan row of a service detail page.
+type Project struct {
+	Name      string
+	Borrower  string
+	Amount    string
+	Rate      string
+	Term      string
+	Status    string
+	Contracts []Contract
+}
+
+// Service is a smartbid order with its detail page.
+type Service struct {
+	ID        string
+	Name      string
+	Status    int
+	Agreement *Contract
+	Projects  []Project
+	// Amount is 10000.00 when left empty, the other fields are left out of
+	// the detail page when empty
+	Amount string
+	Rate   string
+	Term   string
+	Start  string
+	End    string
+	State  string
+}
+
+func (sv Service) amount() string {
+	if sv.Amount == "" {
+		return "10000.00"
+	}
+
+	return sv.Amount
+}
+
+type Server struct {
+	*httptest.Server
+	Trades   []Trade
+	Services []Service
+	// PageSize applies to the order list, the trade list honours size
+	PageSize int
+	// ProjectPageSize pages the project table of detail pages, 0 shows every row
+	ProjectPageSize int
+	// LinkPager renders the project pager's next link as a plain link to the
+	// next page. By default it is a script link carrying only data-page, the
+	// way the live pager filling the table by javascript is
+	LinkPager bool
+	// AfterTradePage runs once a trade list page is served, e.g. to add rows
+	// on top the way the live site does while the list is paged through
+	AfterTradePage func(page int)
+	// ContractStatus answers a contract request with the status it returns
+	// instead of the file, e.g. 429 while the site is busy, 0 serves the file
+	ContractStatus func(id string) int
+}
+
+// NewServer starts serving trades and services, Close it when done.
+func NewServer(trades []Trade, services []Service) *Server {
+	s := &Server{Trades: trades, Services: services, PageSize: 10}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user/trade/datalist", s.auth(s.tradeList))
+	mux.HandleFunc("/user/smartbid/order/datalist", s.auth(s.orderList))
+	mux.HandleFunc("/user/smartbid/order/detail", s.auth(s.orderDetail))
+	mux.HandleFunc("/user/contract/download", s.auth(s.contract))
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Cookies is the cookie setting the exporter and downloader take.
+func (s *Server) Cookies() []map[string]interface{} {
+	return []map[string]interface{}{
+		{"Name": CookieName, "Value": CookieValue, "Path": "/"},
+	}
+}
+
+// Site points the exporter and downloader at the server.
+func (s *Server) Site() site.Site {
+	return site.Site{BaseUrl: s.URL}.WithDefaults()
+}
+
+// Trades builds n trades, newest first, with a consistent balance chain.
+func Trades(n int) []Trade {
+	trades := make([]Trade, n)
+	balance := 0
+
+	for i := n - 1; i >= 0; i-- {
+		j := n - 1 - i
+		t := Trade{Type: 1, Desc: "充值", Time: fmt.Sprintf("2019-01-%02d 10:%02d:00", 1+j/60, j%60)}
+		amount := 10000 + j
+
+		if j%3 == 1 {
+			t.Type, t.Desc, amount = 3, "投资", -(amount / 2)
+		}
+
+		balance += amount
+		t.Amount = signed(amount)
+		t.Balance = signed(balance)[1:]
+		trades[i] = t
+	}
+
+	return trades
+}
+
+func signed(fen int) string {
+	sign := "+"
+	if fen < 0 {
+		sign, fen = "-", -fen
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, fen/100, fen%100)
+}
+
+func (s *Server) auth(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie(CookieName); err != nil || c.Value != CookieValue {
+			if r.URL.Path == "/user/contract/download" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+
+			fmt.Fprint(w, `<html><body><div class="login">请登录</div></body></html>`)
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+func intParam(r *http.Request, name string, def int) int {
+	if v, err := strconv.Atoi(r.URL.Query().Get(name)); err == nil {
+		return v
+	}
+
+	return def
+}
+
+func window(total, page, size int) (int, int) {
+	from := (page - 1) * size
+	if from > total {
+		from = total
+	}
+
+	to := from + size
+	if to > total {
+		to = total
+	}
+
+	return from, to
+}
+
+func (s *Server) tradeList(w http.ResponseWriter, r *http.Request) {
+	kind := intParam(r, "type", 0)
+	page := intParam(r, "page", 1)
+	size := intParam(r, "size", 10)
+
+	trades := []Trade{}
+	for _, t := range s.Trades {
+		if kind == 0 || t.Type == kind {
+			trades = append(trades, t)
+		}
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(`<table><thead><tr><th>交易金额</th><th>说明</th>`)
+	if kind == 0 {
+		b.WriteString(`<th>账户余额</th>`)
+	}
+	b.WriteString(`<th>交易时间</th></tr></thead><tbody>`)
+
+	from, to := window(len(trades), page, size)
+	for _, t := range trades[from:to] {
+		fmt.Fprintf(b, `<tr><td><span class="amount">%s</span></td><td>%s</td>`, t.Amount, html.EscapeString(t.Desc))
+		if kind == 0 {
+			fmt.Fprintf(b, `<td>%s</td>`, t.Balance)
+		}
+		fmt.Fprintf(b, `<td>%s</td></tr>`, t.Time)
+	}
+
+	if from == to {
+		b.WriteString(`<tr><td colspan="4">暂无数据</td></tr>`)
+	}
+	b.WriteString(`</tbody></table>`)
+
+	if s.AfterTradePage != nil {
+		defer s.AfterTradePage(page)
+	}
+
+	fmt.Fprintf(w, `<html><body><div class="g-uc-page clearfix"><div class="g-uc-side"></div><div class="g-uc-main"><div class="m-trade"><div class="hd">交易记录</div><div class="bd"><div class="filter"></div><div class="list">%s</div></div></div></div></div></body></html>`, b.String())
+}
+
+func (s *Server) orderList(w http.ResponseWriter, r *http.Request) {
+	status := intParam(r, "status", 0)
+	page := intParam(r, "page", 1)
+
+	services := []Service{}
+	for _, sv := range s.Services {
+		if status == 0 || sv.Status == status {
+			services = append(services, sv)
+		}
+	}
+
+	b := &strings.Builder{}
+	from, to := window(len(services), page, s.PageSize)
+	for _, sv := range services[from:to] {
+		fmt.Fprintf(b, `<li><div class="info"><div class="name">%s</div><div class="amount">%s</div></div><a class="btn actionBtn" href="/user/smartbid/order/detail?id=%s&amp;smb_type=1">详情</a></li>`,
+			html.EscapeString(sv.Name), sv.amount(), sv.ID)
+	}
+
+	fmt.Fprintf(w, `<html><body><div class="g-uc-page clearfix"><div class="g-uc-side"></div><div class="g-uc-main"><div class="m-order"><ul>%s</ul></div></div></div></body></html>`, b.String())
+}
+
+func (s *Server) service(id string) *Service {
+	for i := range s.Services {
+		if s.Services[i].ID == id {
+			return &s.Services[i]
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) orderDetail(w http.ResponseWriter, r *http.Request) {
+	sv := s.service(r.URL.Query().Get("id"))
+	if sv == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, `<div class="hd"><a class="a-title">%s</a></div>`, html.EscapeString(sv.Name))
+
+	b.WriteString(`<ul class="order-info">`)
+	for _, field := range [][2]string{
+		{"加入金额", sv.amount() + "元"}, {"预期年化", sv.Rate}, {"服务期限", sv.Term},
+		{"起息日", sv.Start}, {"到期日", sv.End}, {"状态", sv.State},
+	} {
+		if field[1] != "" {
+			fmt.Fprintf(b, `<li><span class="label">%s</span><span class="value">%s</span></li>`, field[0], html.EscapeString(field[1]))
+		}
+	}
+	b.WriteString(`</ul>`)
+
+	if sv.Agreement != nil {
+		fmt.Fprintf(b, `<div class="clearfix"><a href="/user/contract/download?id=%s">服务协议</a></div>`, sv.Agreement.ID)
+	}
+
+	projects := sv.Projects
+	page := intParam(r, "prj_page", 1)
+	size := s.ProjectPageSize
+	if size <= 0 {
+		size = len(projects) + 1
+	}
+
+	from, to := window(len(projects), page, size)
+
+	b.WriteString(`<table><thead><tr><th>序号</th><th>项目名称</th><th>借款人</th><th>匹配金额</th><th>年化利率</th><th>期限</th><th>还款状态</th><th>操作</th></tr></thead><tbody id="buy_prj_relation_list">`)
+	for i, p := range projects[from:to] {
+		fmt.Fprintf(b, `<tr><td>%d</td><td>%s</td>`, from+i+1, html.EscapeString(p.Name))
+		for _, cell := range []string{p.Borrower, p.Amount, p.Rate, p.Term, p.Status} {
+			fmt.Fprintf(b, `<td>%s</td>`, html.EscapeString(cell))
+		}
+		b.WriteString(`<td><div class="details-panel"><table><tr>`)
+		for _, c := range p.Contracts {
+			fmt.Fprintf(b, `<td><a href="/user/contract/download?id=%s">合同</a></td>`, c.ID)
+		}
+		b.WriteString(`</tr></table></div></td></tr>`)
+	}
+	b.WriteString(`</tbody></table>`)
+
+	fmt.Fprintf(b, `<div id="buy_prj_relation_pager"><span class="total">共 %d 条</span>`, len(projects))
+	if to < len(projects) && !s.LinkPager {
+		fmt.Fprintf(b, `<a class="next" href="javascript:;" data-page="%d">下一页</a>`, page+1)
+	} else if to < len(projects) {
+		fmt.Fprintf(b, `<a class="next" href="/user/smartbid/order/detail?id=%s&amp;smb_type=1&amp;prj_page=%d">下一页</a>`, sv.ID, page+1)
+	}
+	b.WriteString(`</div>`)
+
+	fmt.Fprintf(w, `<html><body><div class="g-uc-page clearfix no-side"><div class="m-detail"><div class="uc-order-detail">%s</div></div></div></body></html>`, b.String())
+}
+
+func (s *Server) contract(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+
+	if s.ContractStatus != nil {
+		if status := s.ContractStatus(id); status != 0 {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+	}
+
+	for _, sv := range s.Services {
+		contracts := []Contract{}
+		if sv.Agreement != nil {
+			contracts = append(contracts, *sv.Agreement)
+		}
+		for _, p := range sv.Projects {
+			contracts = append(contracts, p.Contracts...)
+		}
+
+		for _, c := range contracts {
+			if c.ID == id {
+				w.Header().Set("Content-Disposition", `attachment; filename="`+c.File+`"`)
+				w.Header().Set("Content-Length", strconv.Itoa(len(c.Body)))
+				w.Write(c.Body)
+				return
+			}
+		}
+	}
+
+	http.NotFound(w, r)
+}
@@ -0,0 +1,168 @@
+// Package selector keeps the CSS selectors used to read lantouzi.com pages in a
+// versioned profile, so a site redesign is a profile change, not a code change.
+package selector
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// Version is the newest profile layout this build understands.
+const Version = 1
+
+// Pages a selector can apply to.
+const (
+	TradeList   = "trade_list"
+	OrderList   = "order_list"
+	OrderDetail = "order_detail"
+)
+
+// Names of the selectors the export and download packages look up.
+const (
+	TradeTable      = "trade_table"
+	OrderListBody   = "order_list_body"
+	OrderItem       = "order_item"
+	OrderName       = "order_name"
+	OrderLink       = "order_link"
+	OrderAmount     = "order_amount"
+	DetailBody      = "detail_body"
+	DetailTitle     = "detail_title"
+	DetailAgreement = "detail_agreement"
+	DetailField     = "detail_field"
+	DetailLabel     = "detail_label"
+	DetailValue     = "detail_value"
+	ProjectReady    = "project_ready"
+	ProjectHeader   = "project_header"
+	ProjectRow      = "project_row"
+	ProjectContract = "project_contract"
+	ProjectTotal    = "project_total"
+	ProjectNext     = "project_next"
+)
+
+type Selector struct {
+	Css string
+	// Page is where the selector applies, nested selectors are matched against
+	// the whole page as well
+	Page string
+	// Optional selectors may match nothing, e.g. a pager on a single page list
+	Optional bool
+}
+
+type Profile struct {
+	Version   int
+	Selectors map[string]Selector
+}
+
+// Default is the profile matching lantouzi.com as this build knows it.
+func Default() Profile {
+	return Profile{
+		Version: Version,
+		Selectors: map[string]Selector{
+			TradeTable:      {Css: "body > div.g-uc-page.clearfix > div.g-uc-main > div > div.bd > div:nth-child(2) > table", Page: TradeList},
+			OrderListBody:   {Css: "body > div.g-uc-page.clearfix > div.g-uc-main > div", Page: OrderList},
+			OrderItem:       {Css: "li", Page: OrderList},
+			OrderName:       {Css: "div[class=name]:first-child", Page: OrderList},
+			OrderLink:       {Css: "a[class~=actionBtn]", Page: OrderList},
+			OrderAmount:     {Css: "div[class=amount]", Page: OrderList},
+			DetailBody:      {Css: "body > div.g-uc-page.clearfix.no-side > div > div.uc-order-detail", Page: OrderDetail},
+			DetailTitle:     {Css: "a[class=a-title]", Page: OrderDetail},
+			DetailAgreement: {Css: "div[class=clearfix] a", Page: OrderDetail},
+			DetailField:     {Css: "ul.order-info > li", Page: OrderDetail},
+			DetailLabel:     {Css: "span.label", Page: OrderDetail},
+			DetailValue:     {Css: "span.value", Page: OrderDetail},
+			ProjectReady:    {Css: "#buy_prj_relation_list > tr:nth-child(1)", Page: OrderDetail},
+			ProjectHeader:   {Css: "table:has(#buy_prj_relation_list) > thead th", Page: OrderDetail},
+			ProjectRow:      {Css: "#buy_prj_relation_list>tr", Page: OrderDetail},
+			ProjectContract: {Css: "div[class=details-panel] td a", Page: OrderDetail},
+			ProjectTotal:    {Css: "#buy_prj_relation_pager .total", Page: OrderDetail, Optional: true},
+			ProjectNext:     {Css: "#buy_prj_relation_pager a.next", Page: OrderDetail, Optional: true},
+		},
+	}
+}
+
+// Load reads a profile file, selectors it leaves out keep their default.
+func Load(file string) (Profile, error) {
+	v := viper.New()
+	v.SetConfigFile(file)
+
+	if err := v.ReadInConfig(); err != nil {
+		return Profile{}, errors.WithMessagef(err, "Load: read profile fail -> %s", file)
+	}
+
+	loaded := Profile{}
+	if err := v.Unmarshal(&loaded); err != nil {
+		return Profile{}, errors.WithMessagef(err, "Load: parse profile fail -> %s", file)
+	}
+
+	if loaded.Version < 1 || loaded.Version > Version {
+		return Profile{}, errors.Errorf("Load: unsupported profile version %d, want 1 to %d -> %s", loaded.Version, Version, file)
+	}
+
+	p := Default()
+	p.Version = loaded.Version
+
+	for name, sel := range loaded.Selectors {
+		name = strings.ToLower(name)
+		if sel.Page == "" {
+			sel.Page = p.Selectors[name].Page
+		}
+		p.Selectors[name] = sel
+	}
+
+	return p, p.Validate()
+}
+
+// Validate makes sure every selector is set and parses.
+func (p Profile) Validate() error {
+	for name, sel := range p.Selectors {
+		if strings.TrimSpace(sel.Css) == "" {
+			return errors.Errorf("Validate: empty selector %s", name)
+		}
+
+		if _, err := cascadia.Compile(sel.Css); err != nil {
+			return errors.WithMessagef(err, "Validate: invalid selector %s", name)
+		}
+	}
+
+	return nil
+}
+
+// Css returns the named selector, empty when the profile has no such name.
+func (p Profile) Css(name string) string {
+	return p.Selectors[name].Css
+}
+
+// Result tells how often a selector matched on a rendered page.
+type Result struct {
+	Name     string
+	Css      string
+	Matches  int
+	Optional bool
+}
+
+// Check matches every selector of page against a rendered document.
+func (p Profile) Check(page, doc string) ([]Result, error) {
+	results := []Result{}
+
+	dom, err := goquery.NewDocumentFromReader(strings.NewReader(doc))
+	if err != nil {
+		return results, errors.WithMessage(err, "Check: load html to dom fail")
+	}
+
+	for name, sel := range p.Selectors {
+		if sel.Page != page {
+			continue
+		}
+
+		results = append(results, Result{Name: name, Css: sel.Css, Matches: dom.Find(sel.Css).Length(), Optional: sel.Optional})
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	return results, nil
+}
@@ -0,0 +1,102 @@
+package selector_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/HarryBird/lantouzi-export/fetcher"
+	"github.com/HarryBird/lantouzi-export/ltztest"
+	"github.com/HarryBird/lantouzi-export/selector"
+)
+
+func TestLoad(t *testing.T) {
+	p, err := selector.Load("../selectors.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(p, selector.Default()) {
+		t.Errorf("selectors.yaml drifted from the default profile:\n%+v\n%+v", p, selector.Default())
+	}
+}
+
+func TestLoadOverride(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "selectors.yaml")
+
+	profile := "version: 1\nselectors:\n    order_item:\n        css: \"ul > li.order\"\n"
+	if err := ioutil.WriteFile(file, []byte(profile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := selector.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := p.Selectors[selector.OrderItem]; got.Css != "ul > li.order" || got.Page != selector.OrderList {
+		t.Errorf("override not applied: %+v", got)
+	}
+
+	if p.Css(selector.TradeTable) != selector.Default().Css(selector.TradeTable) {
+		t.Error("selectors left out of the file lost their default")
+	}
+
+	if err := ioutil.WriteFile(file, []byte("version: 99\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := selector.Load(file); err == nil {
+		t.Error("expected an error for an unknown profile version")
+	}
+
+	if err := ioutil.WriteFile(file, []byte("version: 1\nselectors:\n    order_item:\n        css: \"li[\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := selector.Load(file); err == nil {
+		t.Error("expected an error for an invalid css selector")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	srv := ltztest.NewServer(ltztest.Trades(3), []ltztest.Service{{
+		ID:        "s1",
+		Name:      "智选服务6月期D1",
+		Agreement: &ltztest.Contract{ID: "a1", File: "agreement.pdf"},
+		Projects:  []ltztest.Project{{Name: "项目A", Contracts: []ltztest.Contract{{ID: "c1", File: "loan.pdf"}}}},
+	}})
+	defer srv.Close()
+
+	f, err := fetcher.NewHTTP(nil, srv.Cookies())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trade, _ := srv.Site().TradeUrl(0)
+	orders, _ := srv.Site().OrderListUrl(0, 1)
+	detail, _ := srv.Site().Resolve("/user/smartbid/order/detail?id=s1&smb_type=1")
+
+	for page, url := range map[string]string{selector.TradeList: trade, selector.OrderList: orders, selector.OrderDetail: detail} {
+		p, err := f.Fetch(fetcher.Request{URL: url, Document: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		results, err := selector.Default().Check(page, p.Document)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(results) == 0 {
+			t.Errorf("no selectors checked for %s", page)
+		}
+
+		for _, r := range results {
+			if r.Matches == 0 && !r.Optional {
+				t.Errorf("%s: selector %s %q matched nothing", page, r.Name, r.Css)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Package site describes where lantouzi.com and its pages live, so the tool
+// can be pointed at a mirror, a staging host or a local stand-in server.
+package site
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type Site struct {
+	BaseUrl       string `mapstructure:"base_url"`
+	TradePath     string `mapstructure:"trade_path"`
+	OrderListPath string `mapstructure:"order_list_path"`
+}
+
+// Default is the live lantouzi.com site.
+func Default() Site {
+	return Site{
+		BaseUrl:       "https://lantouzi.com",
+		TradePath:     "/user/trade/datalist",
+		OrderListPath: "/user/smartbid/order/datalist",
+	}
+}
+
+// WithDefaults fills every field left empty from Default.
+func (s Site) WithDefaults() Site {
+	d := Default()
+
+	if s.BaseUrl == "" {
+		s.BaseUrl = d.BaseUrl
+	}
+
+	if s.TradePath == "" {
+		s.TradePath = d.TradePath
+	}
+
+	if s.OrderListPath == "" {
+		s.OrderListPath = d.OrderListPath
+	}
+
+	s.BaseUrl = strings.TrimSuffix(s.BaseUrl, "/")
+
+	return s
+}
+
+// Resolve turns a link found on a page, or a configured path, into an absolute url.
+func (s Site) Resolve(ref string) (string, error) {
+	base, err := url.Parse(s.BaseUrl + "/")
+	if err != nil {
+		return "", errors.WithMessagef(err, "Resolve: invalid base url -> %s", s.BaseUrl)
+	}
+
+	r, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return "", errors.WithMessagef(err, "Resolve: invalid url -> %s", ref)
+	}
+
+	return base.ResolveReference(r).String(), nil
+}
+
+// Url resolves path and merges query into whatever query it already carries.
+func (s Site) Url(path string, query url.Values) (string, error) {
+	full, err := s.Resolve(path)
+	if err != nil {
+		return "", err
+	}
+
+	return WithQuery(full, query)
+}
+
+// TradeUrl is a page of the trade list, kind 0 meaning all trade types.
+func (s Site) TradeUrl(kind int) (string, error) {
+	query := url.Values{}
+	if kind != 0 {
+		query.Set("type", strconv.Itoa(kind))
+	}
+
+	return s.Url(s.TradePath, query)
+}
+
+// OrderListUrl is a page of the smartbid order list.
+func (s Site) OrderListUrl(status, page int) (string, error) {
+	return s.Url(s.OrderListPath, url.Values{
+		"status": {strconv.Itoa(status)},
+		"page":   {strconv.Itoa(page)},
+	})
+}
+
+// WithQuery sets every value of query on the url, replacing existing ones.
+func WithQuery(raw string, query url.Values) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", errors.WithMessagef(err, "WithQuery: invalid url -> %s", raw)
+	}
+
+	q := u.Query()
+	for k, v := range query {
+		q[k] = v
+	}
+
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,45 @@
+package site
+
+import (
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	s := Site{BaseUrl: "http://127.0.0.1:8080/"}.WithDefaults()
+
+	cases := map[string]string{
+		"/user/contract/download?id=1":             "http://127.0.0.1:8080/user/contract/download?id=1",
+		"https://lantouzi.com/user/trade/datalist": "https://lantouzi.com/user/trade/datalist",
+		" /user/trade/datalist?type=1 ":            "http://127.0.0.1:8080/user/trade/datalist?type=1",
+	}
+
+	for in, want := range cases {
+		got, err := s.Resolve(in)
+		if err != nil || got != want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestUrls(t *testing.T) {
+	s := Default()
+
+	cases := []struct {
+		got  func() (string, error)
+		want string
+	}{
+		{func() (string, error) { return s.TradeUrl(0) }, "https://lantouzi.com/user/trade/datalist"},
+		{func() (string, error) { return s.TradeUrl(3) }, "https://lantouzi.com/user/trade/datalist?type=3"},
+		{func() (string, error) { return s.OrderListUrl(3, 2) }, "https://lantouzi.com/user/smartbid/order/datalist?page=2&status=3"},
+		{func() (string, error) {
+			return WithQuery("https://lantouzi.com/user/trade/datalist?type=1&page=1", map[string][]string{"page": {"2"}})
+		}, "https://lantouzi.com/user/trade/datalist?page=2&type=1"},
+	}
+
+	for _, c := range cases {
+		got, err := c.got()
+		if err != nil || got != c.want {
+			t.Errorf("got %q, %v, want %q", got, err, c.want)
+		}
+	}
+}
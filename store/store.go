@@ -0,0 +1,219 @@
+// Package store keeps transactions, services, loan projects and contract
+// files in one SQLite database. Every row has a stable primary key and is
+// written with an upsert, so running export or download again updates the
+// rows in place instead of adding duplicates.
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	// registers the sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/HarryBird/lantouzi-export/download"
+	"github.com/HarryBird/lantouzi-export/export"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS transactions (
+	id          TEXT NOT NULL,
+	category    TEXT NOT NULL,
+	time        TEXT NOT NULL,
+	direction   TEXT NOT NULL,
+	amount      INTEGER NOT NULL,
+	description TEXT NOT NULL,
+	balance     INTEGER,
+	page        INTEGER NOT NULL,
+	row         INTEGER NOT NULL,
+	updated_at  TEXT NOT NULL,
+	PRIMARY KEY (category, id)
+);
+CREATE INDEX IF NOT EXISTS transactions_category_time ON transactions (category, time);
+
+CREATE TABLE IF NOT EXISTS services (
+	url         TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	folder      TEXT NOT NULL,
+	dead        INTEGER NOT NULL,
+	amount      INTEGER NOT NULL,
+	rate        TEXT NOT NULL,
+	term        TEXT NOT NULL,
+	start       TEXT NOT NULL,
+	"end"       TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	status_code INTEGER NOT NULL,
+	status_name TEXT NOT NULL,
+	updated_at  TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+	service_url TEXT NOT NULL,
+	service     TEXT NOT NULL,
+	name        TEXT NOT NULL,
+	borrower    TEXT NOT NULL,
+	amount      INTEGER NOT NULL,
+	rate        TEXT NOT NULL,
+	term        TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	extra       TEXT NOT NULL,
+	updated_at  TEXT NOT NULL,
+	PRIMARY KEY (service_url, name)
+);
+
+CREATE TABLE IF NOT EXISTS contracts (
+	url        TEXT PRIMARY KEY,
+	service    TEXT NOT NULL,
+	project    TEXT NOT NULL,
+	path       TEXT NOT NULL,
+	sha256     TEXT NOT NULL,
+	size       INTEGER NOT NULL,
+	updated_at TEXT NOT NULL
+);
+`
+
+// Store is an open database, Close it when done.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens or creates the database file and its tables.
+func Open(file string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, errors.WithMessagef(err, "Open: create dir fail -> %s", file)
+	}
+
+	db, err := sql.Open("sqlite3", file)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "Open: open db fail -> %s", file)
+	}
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, errors.WithMessagef(err, "Open: create tables fail -> %s", file)
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func now() string {
+	return time.Now().Format(time.RFC3339)
+}
+
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format("2006-01-02")
+}
+
+// SaveTransactions upserts the records of one export target, keyed by
+// target and Transaction.ID.
+func (s *Store) SaveTransactions(records []export.Transaction) error {
+	return s.tx(func(tx *sql.Tx) error {
+		stmt, err := tx.Prepare(`INSERT INTO transactions (id, category, time, direction, amount, description, balance, page, row, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (category, id) DO UPDATE SET page = excluded.page, row = excluded.row, updated_at = excluded.updated_at`)
+		if err != nil {
+			return err
+		}
+
+		defer stmt.Close()
+
+		at := now()
+
+		for _, r := range records {
+			var balance interface{}
+			if r.Balance != nil {
+				balance = int64(*r.Balance)
+			}
+
+			if _, err := stmt.Exec(r.ID, r.Category, r.Time.Format(time.RFC3339), r.Direction.String(),
+				int64(r.Amount), r.Description, balance, r.Page, r.Row, at); err != nil {
+				return errors.WithMessagef(err, "SaveTransactions: upsert fail -> %s %s", r.Category, r.Time)
+			}
+		}
+
+		return nil
+	})
+}
+
+// SaveServices upserts services and the loan projects found under them, keyed
+// by the service url since names repeat across orders.
+func (s *Store) SaveServices(services []download.Service, projects []download.LoanProject) error {
+	return s.tx(func(tx *sql.Tx) error {
+		at := now()
+
+		for _, sv := range services {
+			if _, err := tx.Exec(`INSERT INTO services (name, url, folder, dead, amount, rate, term, start, "end", status, status_code, status_name, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (url) DO UPDATE SET name = excluded.name, folder = excluded.folder, dead = excluded.dead,
+					amount = excluded.amount, rate = excluded.rate, term = excluded.term, start = excluded.start,
+					"end" = excluded."end", status = excluded.status, status_code = excluded.status_code,
+					status_name = excluded.status_name, updated_at = excluded.updated_at`,
+				sv.Name, sv.Url, sv.Folder, sv.Dead, int64(sv.Amount), sv.Rate, sv.Term, date(sv.Start), date(sv.End), sv.Status,
+				sv.OrderStatus.Code, sv.OrderStatus.Name, at); err != nil {
+				return errors.WithMessagef(err, "SaveServices: upsert service fail -> %s", sv.Name)
+			}
+		}
+
+		for _, p := range projects {
+			extra, err := json.Marshal(p.Extra)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.Exec(`INSERT INTO projects (service_url, service, name, borrower, amount, rate, term, status, extra, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (service_url, name) DO UPDATE SET service = excluded.service, borrower = excluded.borrower, amount = excluded.amount,
+					rate = excluded.rate, term = excluded.term, status = excluded.status, extra = excluded.extra, updated_at = excluded.updated_at`,
+				p.ServiceUrl, p.Service, p.Name, p.Borrower, int64(p.Amount), p.Rate, p.Term, p.Status, string(extra), at); err != nil {
+				return errors.WithMessagef(err, "SaveServices: upsert project fail -> %s %s", p.Service, p.Name)
+			}
+		}
+
+		return nil
+	})
+}
+
+// SaveContracts upserts contract file metadata, keyed by the source url.
+func (s *Store) SaveContracts(contracts []download.Contract) error {
+	return s.tx(func(tx *sql.Tx) error {
+		at := now()
+
+		for _, c := range contracts {
+			if _, err := tx.Exec(`INSERT INTO contracts (url, service, project, path, sha256, size, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (url) DO UPDATE SET service = excluded.service, project = excluded.project, path = excluded.path,
+					sha256 = excluded.sha256, size = excluded.size, updated_at = excluded.updated_at`,
+				c.Url, c.Service, c.Project, c.Path, c.Sha256, c.Size, at); err != nil {
+				return errors.WithMessagef(err, "SaveContracts: upsert fail -> %s", c.Url)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *Store) tx(fn func(*sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
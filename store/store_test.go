@@ -0,0 +1,77 @@
+package store_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/HarryBird/lantouzi-export/download"
+	"github.com/HarryBird/lantouzi-export/export"
+	"github.com/HarryBird/lantouzi-export/store"
+)
+
+func count(t *testing.T, file, table string) int {
+	db, err := sql.Open("sqlite3", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	n := 0
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	return n
+}
+
+func TestUpsert(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ltz.db")
+
+	when, err := export.ParseTime("2019-01-01 10:00:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := export.Transaction{ID: "a-0", Amount: 10000, Direction: export.In, Description: "充值", Time: when, Category: "全部", Page: 1, Row: 1}
+	// the same content twice is two rows with their own IDs
+	twin := tx
+	twin.ID, twin.Row = "a-1", 2
+	records := []export.Transaction{tx, twin}
+
+	// two orders of a service share its name
+	services := []download.Service{
+		{Name: "智选服务6月期D1", Url: "https://example.com/s1", Folder: "3/智选服务6月期D1"},
+		{Name: "智选服务6月期D1", Url: "https://example.com/s2", Folder: "4/智选服务6月期D1"},
+	}
+	projects := []download.LoanProject{
+		{Service: "智选服务6月期D1", ServiceUrl: "https://example.com/s1", Name: "项目A"},
+		{Service: "智选服务6月期D1", ServiceUrl: "https://example.com/s2", Name: "项目A"},
+	}
+	contracts := []download.Contract{{Service: "智选服务6月期D1", Project: "项目A", Url: "https://example.com/c1", Path: "1_loan.pdf", Sha256: "00", Size: 6}}
+
+	for run := 0; run < 2; run++ {
+		s, err := store.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.SaveTransactions(records); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SaveServices(services, projects); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SaveContracts(contracts); err != nil {
+			t.Fatal(err)
+		}
+
+		s.Close()
+	}
+
+	for table, want := range map[string]int{"transactions": 2, "services": 2, "projects": 2, "contracts": 1} {
+		if n := count(t, file, table); n != want {
+			t.Errorf("%s has %d rows after two runs, want %d", table, n, want)
+		}
+	}
+}